@@ -38,17 +38,20 @@ func main() {
 		}
 	}
 
-	for btn, col := range colors {
-		err = dev.SetPadColor(btn, col)
-		log.ErrorIf(err, "failed to ")
-	}
-	dev.SetDial(0)
-	for _, btn := range button2.Mutes() {
-		dev.SetBrightness(btn, 127)
-	}
-	for _, btn := range button2.Functions() {
-		dev.SetBrightness(btn, 255)
-	}
+	err = dev.Batch(func(tx *device.Tx) error {
+		for btn, col := range colors {
+			tx.SetPadColor(btn, col)
+		}
+		tx.SetDial(0)
+		for _, btn := range button2.Mutes() {
+			tx.SetBrightness(btn, 127)
+		}
+		for _, btn := range button2.Functions() {
+			tx.SetBrightness(btn, 255)
+		}
+		return nil
+	})
+	log.ErrorIf(err, "failed to set startup state")
 
 	// Start event listening
 	events := make(chan event2.Event, 100)
@@ -80,16 +83,14 @@ func main() {
 				dial = 0
 				dev.SetDial(0)
 
-			case evt.Type == event2.Decrement:
-				if dial > -99 {
-					dial--
-				}
-				dev.SetDial(dial)
-
-			case evt.Type == event2.Increment:
-				if dial < 99 {
-					dial++
+			case evt.Type == event2.Decrement, evt.Type == event2.Increment:
+				value := int(dial) + int(evt.Delta)
+				if value < -99 {
+					value = -99
+				} else if value > 99 {
+					value = 99
 				}
+				dial = int8(value)
 				dev.SetDial(dial)
 			}
 		}
@@ -0,0 +1,48 @@
+/*
+	Package capture records and reads the raw HID traffic exchanged with a F1.
+
+	A unit is recorded by opening it with desc.OpenWrapped(capture.Wrapper(writer)).
+
+	File format, every integer is little endian:
+
+		Header
+			[7]byte  magic "GOF1CAP"
+			byte     format version, currently 1
+			int64    wall clock time of the capture start, in nanoseconds since the unix epoch
+
+		Records, repeated until the end of the file
+			byte     direction, 1 = input report (F1 to host), 2 = output report (host to F1)
+			int64    monotonic time elapsed since the capture start, in nanoseconds
+			uint16   length of the report
+			[]byte   report, as read from or written to the HID device
+*/
+package capture
+
+import (
+	"fmt"
+	"time"
+)
+
+//go:generate go-enum -f=$GOFILE --noprefix
+
+/*
+	Direction x ENUM(
+	Input = 1
+	Output
+)
+*/
+type Direction byte
+
+const Magic = "GOF1CAP"
+const Version = 1
+
+// Record is a single report of a capture
+type Record struct {
+	Direction Direction
+	Time      time.Duration // elapsed since the capture start
+	Report    []byte
+}
+
+func (r Record) String() string {
+	return fmt.Sprintf("%v %v: % x", r.Time, r.Direction, r.Report)
+}
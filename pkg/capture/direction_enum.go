@@ -0,0 +1,43 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package capture
+
+import (
+	"fmt"
+)
+
+const (
+	// Input is a Direction of type Input.
+	Input Direction = iota + 1
+	// Output is a Direction of type Output.
+	Output
+)
+
+const _DirectionName = "InputOutput"
+
+var _DirectionMap = map[Direction]string{
+	1: _DirectionName[0:5],
+	2: _DirectionName[5:11],
+}
+
+// String implements the Stringer interface.
+func (x Direction) String() string {
+	if str, ok := _DirectionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("Direction(%d)", x)
+}
+
+var _DirectionValue = map[string]Direction{
+	_DirectionName[0:5]:  1,
+	_DirectionName[5:11]: 2,
+}
+
+// ParseDirection attempts to convert a string to a Direction
+func ParseDirection(name string) (Direction, error) {
+	if x, ok := _DirectionValue[name]; ok {
+		return x, nil
+	}
+	return Direction(0), fmt.Errorf("%s is not a valid Direction", name)
+}
@@ -0,0 +1,68 @@
+package capture
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Reader reads the records of a capture file.
+type Reader struct {
+	r     io.Reader
+	start time.Time
+}
+
+/*
+	NewReader reads and validates the capture header.
+*/
+func NewReader(r io.Reader) (*Reader, error) {
+	header := make([]byte, len(Magic)+1+8)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read capture header")
+	}
+
+	if string(header[:len(Magic)]) != Magic {
+		return nil, errors.New("not a F1 capture file")
+	}
+	if header[len(Magic)] != Version {
+		return nil, errors.Errorf("unsupported capture version %d", header[len(Magic)])
+	}
+
+	return &Reader{
+		r:     r,
+		start: time.Unix(0, int64(binary.LittleEndian.Uint64(header[len(Magic)+1:]))),
+	}, nil
+}
+
+/*
+	Next returns the next record, io.EOF is returned at the end of the capture.
+*/
+func (r *Reader) Next() (Record, error) {
+	head := make([]byte, 1+8+2)
+	_, err := io.ReadFull(r.r, head)
+	if err == io.EOF {
+		return Record{}, io.EOF
+	} else if err != nil {
+		return Record{}, errors.WithMessage(err, "failed to read capture record")
+	}
+
+	rec := Record{
+		Direction: Direction(head[0]),
+		Time:      time.Duration(binary.LittleEndian.Uint64(head[1:])),
+		Report:    make([]byte, binary.LittleEndian.Uint16(head[9:])),
+	}
+
+	_, err = io.ReadFull(r.r, rec.Report)
+	if err != nil {
+		return Record{}, errors.WithMessage(err, "failed to read capture record")
+	}
+	return rec, nil
+}
+
+// Start returns the wall clock time at which the capture started.
+func (r *Reader) Start() time.Time {
+	return r.start
+}
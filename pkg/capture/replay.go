@@ -0,0 +1,104 @@
+package capture
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrReplayClosed = errors.New("replay is closed")
+
+/*
+	Replay is a device.Transport feeding the input reports of a capture to a Device, as if the
+	recorded F1 was connected. Output reports written by the device are discarded.
+
+	Reports are delivered with their original timing divided by speed, a speed of 2 replays twice
+	as fast, a speed of 0 or less replays as fast as possible. Once every report was delivered, Read
+	blocks until the replay is closed so the device sees an idle F1 rather than a lost one, Done
+	signals the end of the capture.
+*/
+type Replay struct {
+	reader *Reader
+	speed  float64
+
+	base    time.Duration // time of the first input report
+	started time.Time
+
+	done      chan struct{}
+	doneOnce  sync.Once
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewReplay(reader *Reader, speed float64) *Replay {
+	return &Replay{
+		reader: reader,
+		speed:  speed,
+		done:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+func (p *Replay) Read(report []byte) (int, error) {
+	for {
+		select {
+		case <-p.closed:
+			return 0, ErrReplayClosed
+		default:
+		}
+
+		rec, err := p.reader.Next()
+		if err == io.EOF {
+			p.doneOnce.Do(func() { close(p.done) })
+			<-p.closed
+			return 0, ErrReplayClosed
+		} else if err != nil {
+			return 0, err
+		}
+
+		if rec.Direction != Input {
+			continue
+		}
+
+		if p.started.IsZero() {
+			p.started = time.Now()
+			p.base = rec.Time
+		}
+
+		if p.speed > 0 {
+			at := p.started.Add(time.Duration(float64(rec.Time-p.base) / p.speed))
+			select {
+			case <-time.After(time.Until(at)):
+			case <-p.closed:
+				return 0, ErrReplayClosed
+			}
+		}
+
+		return copy(report, rec.Report), nil
+	}
+}
+
+func (p *Replay) Write(report []byte) (int, error) {
+	select {
+	case <-p.closed:
+		return 0, ErrReplayClosed
+	default:
+		return len(report), nil
+	}
+}
+
+func (p *Replay) Close() error {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return nil
+}
+
+// Done returns a channel closed once every report of the capture was delivered.
+func (p *Replay) Done() <-chan struct{} {
+	return p.done
+}
+
+func (p *Replay) String() string {
+	return "capture replay"
+}
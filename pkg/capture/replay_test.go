@@ -0,0 +1,82 @@
+package capture_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/draeron/gof1/pkg/capture"
+	"github.com/draeron/gof1/pkg/device"
+	"github.com/draeron/gof1/pkg/f1/button"
+	"github.com/draeron/gof1/pkg/f1/event"
+)
+
+func TestReplayEnd(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer, err := capture.NewWriter(buffer)
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+
+	// the pad is still held at the end of the capture
+	in := device.NewInState()
+	idle, _ := in.MarshalBinary()
+	in.PressedButtons[button.PadA1] = button.Pushed
+	pressed, _ := in.MarshalBinary()
+	for _, rec := range []capture.Record{
+		{Direction: capture.Input, Time: 0, Report: idle},
+		{Direction: capture.Input, Time: 100 * time.Millisecond, Report: pressed},
+	} {
+		if err := writer.WriteRecord(rec); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	reader, err := capture.NewReader(buffer)
+	if err != nil {
+		t.Fatalf("reader: %v", err)
+	}
+	replay := capture.NewReplay(reader, 1)
+	dev, err := device.OpenTransport(replay)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer dev.Close()
+
+	events := make(chan event.Event, 100)
+	dev.Subscribe(events)
+
+	select {
+	case <-replay.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("replay didn't end")
+	}
+
+	// the end of the capture is not the loss of the F1
+	received := false
+	timeout := time.After(200 * time.Millisecond)
+wait:
+	for {
+		select {
+		case evt := <-events:
+			if evt.Source == event.Lost || evt.Type.IsConnection() {
+				t.Errorf("unexpected %v at the end of the replay", evt)
+			}
+			if evt.Btn == button.PadA1 && evt.Type == event.Pressed {
+				received = true
+			}
+		case <-dev.Done():
+			t.Fatalf("device was shut down at the end of the replay")
+		case <-timeout:
+			break wait
+		}
+	}
+	if !received {
+		t.Errorf("pad press was not replayed")
+	}
+
+	dev.Close()
+	if _, err := replay.Read(make([]byte, device.InReportSize)); err != capture.ErrReplayClosed {
+		t.Errorf("read after close returned %v", err)
+	}
+}
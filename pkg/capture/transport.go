@@ -0,0 +1,58 @@
+package capture
+
+import (
+	"fmt"
+
+	"github.com/draeron/gof1/pkg/device"
+)
+
+/*
+	Transport records every report going through the wrapped transport. Closing it closes the wrapped
+	transport but not the capture writer.
+*/
+type Transport struct {
+	device.Transport
+	writer *Writer
+}
+
+func Wrap(transport device.Transport, writer *Writer) *Transport {
+	return &Transport{
+		Transport: transport,
+		writer:    writer,
+	}
+}
+
+/*
+	Wrapper returns a function wrapping transports to record them, to be used with device.Descriptor.OpenWrapped.
+	A supervised device keeps recording across reconnections.
+*/
+func Wrapper(writer *Writer) func(device.Transport) device.Transport {
+	return func(transport device.Transport) device.Transport {
+		return Wrap(transport, writer)
+	}
+}
+
+func (t *Transport) String() string {
+	return fmt.Sprintf("%v (recorded)", t.Transport)
+}
+
+func (t *Transport) Read(report []byte) (int, error) {
+	length, err := t.Transport.Read(report)
+	if err == nil && length > 0 {
+		t.record(Input, report[:length])
+	}
+	return length, err
+}
+
+func (t *Transport) Write(report []byte) (int, error) {
+	length, err := t.Transport.Write(report)
+	if err == nil {
+		t.record(Output, report)
+	}
+	return length, err
+}
+
+func (t *Transport) record(dir Direction, report []byte) {
+	err := t.writer.Record(dir, report)
+	log.ErrorIf(err, "failed to record %v report", dir)
+}
@@ -0,0 +1,70 @@
+package capture
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Writer writes records to a capture file, it is safe for concurrent use.
+type Writer struct {
+	mutex sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+/*
+	NewWriter writes the capture header, the capture starts now.
+*/
+func NewWriter(w io.Writer) (*Writer, error) {
+	cw := &Writer{
+		w:     w,
+		start: time.Now(),
+	}
+
+	header := make([]byte, len(Magic)+1+8)
+	copy(header, Magic)
+	header[len(Magic)] = Version
+	binary.LittleEndian.PutUint64(header[len(Magic)+1:], uint64(cw.start.UnixNano()))
+
+	_, err := w.Write(header)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to write capture header")
+	}
+	return cw, nil
+}
+
+// Record writes a report timestamped with the time elapsed since the capture start.
+func (w *Writer) Record(dir Direction, report []byte) error {
+	return w.WriteRecord(Record{
+		Direction: dir,
+		Time:      time.Since(w.start),
+		Report:    report,
+	})
+}
+
+func (w *Writer) WriteRecord(rec Record) error {
+	if len(rec.Report) > 0xFFFF {
+		return errors.Errorf("report of %d bytes is too long", len(rec.Report))
+	}
+
+	data := make([]byte, 1+8+2, 1+8+2+len(rec.Report))
+	data[0] = byte(rec.Direction)
+	binary.LittleEndian.PutUint64(data[1:], uint64(rec.Time))
+	binary.LittleEndian.PutUint16(data[9:], uint16(len(rec.Report)))
+	data = append(data, rec.Report...)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	_, err := w.w.Write(data)
+	return errors.WithMessage(err, "failed to write capture record")
+}
+
+// Start returns the time at which the capture started.
+func (w *Writer) Start() time.Time {
+	return w.start
+}
@@ -0,0 +1,131 @@
+package device
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+)
+
+/*
+	AnalogFilter configures the noise suppression applied to the readings of a fader or knob before
+	they are turned into events.
+*/
+type AnalogFilter struct {
+	// Minimum difference, in 12 bits counts, from the last reported value for a new value to be reported,
+	// up to AnalogMax
+	Hysteresis uint16
+	// Low-pass filter factor in [0,1), 0 disables it and higher values smooth more but add lag. The filter
+	// only advances when a report is received, a control at rest may settle slightly off its reading.
+	Smoothing float64
+	// Readings within this distance, in 12 bits counts, of 0 or AnalogMax snap to the end stop, it must
+	// be below AnalogMax/2
+	DeadZone uint16
+}
+
+// DefaultAnalogFilter removes the jitter of controls at rest while keeping most of the resolution
+var DefaultAnalogFilter = AnalogFilter{
+	Hysteresis: 3,
+	DeadZone:   8,
+}
+
+// analogCount is the number of analog controls, indexed by button from Filter1 to Volume4
+const analogCount = 8
+
+type analogChannel struct {
+	calibration *Calibration
+	filter      AnalogFilter
+	smoothed    float64
+	output      uint16
+	init        bool
+}
+
+/*
+	SetAnalogFilter configures the noise suppression of a fader or knob.
+*/
+func (d *Device) SetAnalogFilter(btn button.Button, filter AnalogFilter) error {
+	if !btn.IsFader() && !btn.IsKnob() {
+		return errors.Errorf("button %v is not an analog control", btn)
+	}
+	if filter.Smoothing < 0 || filter.Smoothing >= 1 {
+		return errors.Errorf("smoothing %v is not in [0,1)", filter.Smoothing)
+	}
+	// the dead zones of both end stops would overlap and every reading would snap to one of them
+	if filter.DeadZone >= AnalogMax/2 {
+		return errors.Errorf("dead zone %v is not below %v", filter.DeadZone, AnalogMax/2)
+	}
+	if filter.Hysteresis > AnalogMax {
+		return errors.Errorf("hysteresis %v is above %v", filter.Hysteresis, AnalogMax)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.analog[btn].filter = filter
+	return nil
+}
+
+// SetAnalogFilterAll configures the noise suppression of every fader and knob.
+func (d *Device) SetAnalogFilterAll(filter AnalogFilter) error {
+	for btn := button.Filter1; btn <= button.Volume4; btn++ {
+		err := d.SetAnalogFilter(btn, filter)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+	processAnalog replaces the readings of the input state by their calibrated and filtered values,
+	caller must hold the lock
+*/
+func (d *Device) processAnalog(in *InState) {
+	for idx := range in.Filters {
+		btn := button.Filter1 + button.Button(idx)
+		in.Filters[idx] = d.processReading(btn, in.Filters[idx])
+	}
+	for idx := range in.Volumes {
+		btn := button.Volume1 + button.Button(idx)
+		in.Volumes[idx] = d.processReading(btn, in.Volumes[idx])
+	}
+}
+
+func (d *Device) processReading(btn button.Button, raw uint16) uint16 {
+	if d.calibrator != nil {
+		d.calibrator.record(btn, raw)
+	}
+	channel := &d.analog[btn]
+	if channel.calibration != nil {
+		raw = channel.calibration.Apply(raw)
+	}
+	return channel.process(raw)
+}
+
+func (c *analogChannel) process(raw uint16) uint16 {
+	if c.init && c.filter.Smoothing > 0 {
+		c.smoothed = c.smoothed*c.filter.Smoothing + float64(raw)*(1-c.filter.Smoothing)
+	} else {
+		c.smoothed = float64(raw)
+	}
+
+	val := uint16(math.Round(c.smoothed))
+	switch {
+	case val <= c.filter.DeadZone:
+		val = 0
+	case val >= AnalogMax-c.filter.DeadZone:
+		val = AnalogMax
+	}
+
+	diff := int(val) - int(c.output)
+	if diff < 0 {
+		diff = -diff
+	}
+
+	// end stops are always reached exactly
+	if !c.init || val == 0 || val == AnalogMax || diff >= int(c.filter.Hysteresis) {
+		c.output = val
+	}
+	c.init = true
+	return c.output
+}
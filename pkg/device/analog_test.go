@@ -0,0 +1,34 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+)
+
+func TestSetAnalogFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		btn    button.Button
+		filter AnalogFilter
+		valid  bool
+	}{
+		{"default", button.Volume1, DefaultAnalogFilter, true},
+		{"limits", button.Filter4, AnalogFilter{Hysteresis: AnalogMax, Smoothing: 0.99, DeadZone: AnalogMax/2 - 1}, true},
+		{"not analog", button.PadA1, DefaultAnalogFilter, false},
+		{"negative smoothing", button.Volume1, AnalogFilter{Smoothing: -0.1}, false},
+		{"smoothing of 1", button.Volume1, AnalogFilter{Smoothing: 1}, false},
+		{"overlapping dead zones", button.Volume1, AnalogFilter{DeadZone: AnalogMax / 2}, false},
+		{"hysteresis above range", button.Volume1, AnalogFilter{Hysteresis: AnalogMax + 1}, false},
+	}
+
+	for _, test := range tests {
+		dev := &Device{}
+		err := dev.SetAnalogFilter(test.btn, test.filter)
+		if test.valid && err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: filter %+v was accepted", test.name, test.filter)
+		}
+	}
+}
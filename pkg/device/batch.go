@@ -0,0 +1,224 @@
+package device
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+	"github.com/draeron/gopkgs/color"
+)
+
+/*
+	Tx groups modifications of the output state which are sent to the device as a single report on Commit.
+
+	Each setter validates its arguments immediately, the modifications are applied on top of the device
+	state at commit time so changes made by others in between are preserved.
+*/
+type Tx struct {
+	device *Device
+	out    OutState
+	ops    []func(out *OutState) error
+	done   bool
+}
+
+// Begin starts a new output transaction.
+func (d *Device) Begin() *Tx {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return &Tx{
+		device: d,
+		out:    d.state.out.Copy(),
+	}
+}
+
+/*
+	Batch runs fn inside a transaction which is committed if fn doesn't return an error.
+*/
+func (d *Device) Batch(fn func(tx *Tx) error) error {
+	tx := d.Begin()
+	err := fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (t *Tx) SetDial(val int8) error {
+	return t.apply(func(out *OutState) error {
+		return out.setDial(val)
+	})
+}
+
+func (t *Tx) SetDisplay(display Display) error {
+	return t.apply(func(out *OutState) error {
+		return out.setDisplay(display)
+	})
+}
+
+func (t *Tx) SetDisplayText(text string) error {
+	display, err := TextDisplay(text)
+	if err != nil {
+		return err
+	}
+	return t.SetDisplay(display)
+}
+
+func (t *Tx) SetDisplayHex(val uint8) error {
+	return t.SetDisplay(HexDisplay(val))
+}
+
+func (t *Tx) SetDisplaySegments(left, right Segments) error {
+	return t.apply(func(out *OutState) error {
+		return out.setDisplaySegments(left, right)
+	})
+}
+
+func (t *Tx) SetDisplayDots(left, right bool) error {
+	return t.apply(func(out *OutState) error {
+		return out.setDisplayDots(left, right)
+	})
+}
+
+func (t *Tx) SetBrightness(btn button.Button, val uint8) error {
+	return t.apply(func(out *OutState) error {
+		return out.setBrightness(btn, val)
+	})
+}
+
+func (t *Tx) SetMuteBrightness(btn button.Button, first, second uint8) error {
+	return t.apply(func(out *OutState) error {
+		return out.setMuteBrightness(btn, first, second)
+	})
+}
+
+func (t *Tx) SetPadColorAll(col color.Color) error {
+	return t.SetPadColorMany(button.Pads(), col)
+}
+
+func (t *Tx) SetPadColorMany(btns []button.Button, col color.Color) error {
+	return t.SetPadColors(padColorMany(btns, col))
+}
+
+func (t *Tx) SetPadColor(btn button.Button, col color.Color) error {
+	return t.apply(func(out *OutState) error {
+		return out.setPadColor(btn, col)
+	})
+}
+
+func (t *Tx) SetPadColors(mapp button.ColorMap) error {
+	return t.apply(func(out *OutState) error {
+		return out.setPadColors(mapp)
+	})
+}
+
+// Commit sends every modification of the transaction to the device in a single report.
+func (t *Tx) Commit() error {
+	if t.done {
+		return errors.New("transaction is already done")
+	}
+	t.done = true
+
+	if len(t.ops) == 0 {
+		return nil
+	}
+
+	return t.device.update(func(out *OutState) error {
+		for _, op := range t.ops {
+			err := op(out)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Rollback discards the transaction.
+func (t *Tx) Rollback() {
+	t.done = true
+	t.ops = nil
+}
+
+func (t *Tx) apply(op func(out *OutState) error) error {
+	if t.done {
+		return errors.New("transaction is already done")
+	}
+	err := op(&t.out)
+	if err != nil {
+		return err
+	}
+	t.ops = append(t.ops, op)
+	return nil
+}
+
+/*
+	SetFrameInterval limits the rate of output reports. Modifications made within the interval are merged
+	and sent as a single report at the end of it, errors of delayed writes are logged. An interval of 0
+	sends a report for every modification.
+*/
+func (d *Device) SetFrameInterval(interval time.Duration) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.frameInterval = interval
+	if interval <= 0 {
+		d.flushFrame()
+	}
+}
+
+// write sends the output state now or at the next frame, caller must hold the lock
+func (d *Device) write() error {
+	if d.frameInterval <= 0 {
+		return d.writeNow()
+	}
+
+	d.dirty = true
+	if d.frameTimer != nil {
+		return nil
+	}
+
+	wait := d.frameInterval - time.Since(d.lastWrite)
+	if wait <= 0 {
+		return d.writeNow()
+	}
+	d.frameTimer = time.AfterFunc(wait, d.writeFrame)
+	return nil
+}
+
+func (d *Device) writeFrame() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.frameTimer == nil {
+		return // cancelled
+	}
+	d.frameTimer = nil
+
+	if d.dirty {
+		err := d.writeNow()
+		log.ErrorIf(err, "failed to write frame")
+	}
+}
+
+// writeNow sends the output state immediately, caller must hold the lock
+func (d *Device) writeNow() error {
+	d.dirty = false
+	d.lastWrite = time.Now()
+	err := d.state.out.Write(d.transport)
+	return errors.WithMessage(err, "failed to write to HID device")
+}
+
+// flushFrame cancels the pending frame and sends it, caller must hold the lock
+func (d *Device) flushFrame() {
+	if d.frameTimer != nil {
+		d.frameTimer.Stop()
+		d.frameTimer = nil
+	}
+	if d.dirty {
+		err := d.writeNow()
+		log.ErrorIf(err, "failed to write frame")
+	}
+}
@@ -0,0 +1,223 @@
+package device
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+)
+
+// Calibration is the range of readings measured for an analog control
+type Calibration struct {
+	Min    uint16 `json:"min"`
+	Max    uint16 `json:"max"`
+	Center uint16 `json:"center,omitempty"` // 0 if the center wasn't measured
+}
+
+// Profile holds the calibration of the analog controls of a F1 unit
+type Profile struct {
+	Serial   string                        `json:"serial"`
+	Controls map[button.Button]Calibration `json:"controls"`
+}
+
+/*
+	Apply maps a reading to the full [0,AnalogMax] range. When the center was measured, each half of the
+	range is mapped separately so the center reports exactly the middle value.
+*/
+func (c Calibration) Apply(raw uint16) uint16 {
+	if c.Max <= c.Min {
+		return raw
+	}
+
+	val := float64(raw)
+	mid := float64(AnalogMax) / 2
+	var out float64
+
+	switch {
+	case val <= float64(c.Min):
+		out = 0
+	case val >= float64(c.Max):
+		out = AnalogMax
+	case c.Center > c.Min && c.Center < c.Max && val <= float64(c.Center):
+		out = (val - float64(c.Min)) / float64(c.Center-c.Min) * mid
+	case c.Center > c.Min && c.Center < c.Max:
+		out = mid + (val-float64(c.Center))/float64(c.Max-c.Center)*mid
+	default:
+		out = (val - float64(c.Min)) / float64(c.Max-c.Min) * AnalogMax
+	}
+	return uint16(math.Round(out))
+}
+
+/*
+	Calibrator records the readings of the analog controls. Sweep every fader and knob end to end,
+	optionally call MarkCenter with the knobs at their center position, then call Finish.
+*/
+type Calibrator struct {
+	device  *Device
+	mutex   sync.Mutex
+	min     [analogCount]uint16
+	max     [analogCount]uint16
+	last    [analogCount]uint16
+	center  [analogCount]uint16
+	seen    [analogCount]bool
+	centers bool
+}
+
+// Calibrate starts recording the raw readings of the analog controls.
+func (d *Device) Calibrate() *Calibrator {
+	c := &Calibrator{device: d}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.calibrator = c
+	return c
+}
+
+// MarkCenter records the current position of the knobs as their center.
+func (c *Calibrator) MarkCenter() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.center = c.last
+	c.centers = true
+}
+
+/*
+	Finish stops recording and returns the profile of the device, controls which didn't move are left
+	out. The profile isn't applied nor saved.
+*/
+func (c *Calibrator) Finish() Profile {
+	c.device.mutex.Lock()
+	if c.device.calibrator == c {
+		c.device.calibrator = nil
+	}
+	serial := c.device.descriptor.Serial
+	c.device.mutex.Unlock()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	profile := Profile{
+		Serial:   serial,
+		Controls: map[button.Button]Calibration{},
+	}
+	for idx := range c.seen {
+		if !c.seen[idx] || c.max[idx] <= c.min[idx] {
+			continue
+		}
+		cal := Calibration{Min: c.min[idx], Max: c.max[idx]}
+		btn := button.Button(idx)
+		if c.centers && btn.IsKnob() {
+			cal.Center = c.center[idx]
+		}
+		profile.Controls[btn] = cal
+	}
+	return profile
+}
+
+func (c *Calibrator) record(btn button.Button, raw uint16) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.seen[btn] || raw < c.min[btn] {
+		c.min[btn] = raw
+	}
+	if !c.seen[btn] || raw > c.max[btn] {
+		c.max[btn] = raw
+	}
+	c.last[btn] = raw
+	c.seen[btn] = true
+}
+
+/*
+	SetProfile applies the calibration of the profile, controls missing from it are not calibrated.
+*/
+func (d *Device) SetProfile(profile Profile) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for idx := range d.analog {
+		cal, ok := profile.Controls[button.Button(idx)]
+		if ok {
+			d.analog[idx].calibration = &cal
+		} else {
+			d.analog[idx].calibration = nil
+		}
+	}
+}
+
+// DefaultProfileDir returns the directory where profiles are saved and automatically loaded from.
+func DefaultProfileDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to find user config directory")
+	}
+	return filepath.Join(dir, "gof1", "calibration"), nil
+}
+
+// Save writes the profile as json in the directory, the file is named after the serial number.
+func (p Profile) Save(dir string) error {
+	if p.Serial == "" {
+		return errors.New("profile has no serial number")
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "failed to encode calibration profile")
+	}
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return errors.WithMessage(err, "failed to create calibration directory")
+	}
+
+	err = os.WriteFile(profilePath(dir, p.Serial), data, 0644)
+	return errors.WithMessage(err, "failed to write calibration profile")
+}
+
+// LoadProfile reads the profile of the unit with the serial number from the directory.
+func LoadProfile(dir, serial string) (Profile, error) {
+	data, err := os.ReadFile(profilePath(dir, serial))
+	if err != nil {
+		return Profile{}, errors.WithMessage(err, "failed to read calibration profile")
+	}
+
+	profile := Profile{}
+	err = json.Unmarshal(data, &profile)
+	if err != nil {
+		return Profile{}, errors.WithMessage(err, "failed to decode calibration profile")
+	}
+	return profile, nil
+}
+
+func profilePath(dir, serial string) string {
+	return filepath.Join(dir, serial+".json")
+}
+
+// loadDefaultProfile applies the profile saved in the default directory for this unit, if any
+func (d *Device) loadDefaultProfile() {
+	if d.descriptor.Serial == "" {
+		return
+	}
+
+	dir, err := DefaultProfileDir()
+	if err != nil {
+		log.Warnf("calibration profile not loaded: %v", err)
+		return
+	}
+
+	profile, err := LoadProfile(dir, d.descriptor.Serial)
+	if os.IsNotExist(errors.Cause(err)) {
+		return
+	} else if err != nil {
+		log.Warnf("calibration profile not loaded: %v", err)
+		return
+	}
+
+	log.Infof("applying calibration profile of %s", d.descriptor.Serial)
+	d.SetProfile(profile)
+}
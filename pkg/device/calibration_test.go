@@ -0,0 +1,74 @@
+package device
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+)
+
+func TestCalibrationApply(t *testing.T) {
+	linear := Calibration{Min: 100, Max: 4000}
+	centered := Calibration{Min: 100, Max: 4000, Center: 1000}
+
+	tests := []struct {
+		name string
+		cal  Calibration
+		raw  uint16
+		out  uint16
+	}{
+		{"below min", linear, 0, 0},
+		{"min", linear, 100, 0},
+		{"max", linear, 4000, AnalogMax},
+		{"above max", linear, AnalogMax, AnalogMax},
+		{"middle", linear, 2050, 2048},
+		{"center", centered, 1000, 2048},
+		{"lower half", centered, 550, 1024},
+		{"upper half", centered, 2500, 3071},
+		{"center clamped below", centered, 50, 0},
+		{"center clamped above", centered, 4050, AnalogMax},
+		{"center out of range", Calibration{Min: 100, Max: 4000, Center: 4000}, 2050, 2048},
+		{"max equals min", Calibration{Min: 2000, Max: 2000}, 1234, 1234},
+		{"max below min", Calibration{Min: 3000, Max: 1000}, 1234, 1234},
+		{"not calibrated", Calibration{}, 4000, 4000},
+	}
+
+	for _, test := range tests {
+		if out := test.cal.Apply(test.raw); out != test.out {
+			t.Errorf("%s: %d gave %d, expected %d", test.name, test.raw, out, test.out)
+		}
+	}
+}
+
+func TestProfileSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	profile := Profile{
+		Serial: "ABCD1234",
+		Controls: map[button.Button]Calibration{
+			button.Volume1: {Min: 12, Max: 4080},
+			button.Filter3: {Min: 30, Max: 4000, Center: 2010},
+		},
+	}
+	if err := profile.Save(dir); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := LoadProfile(dir, profile.Serial)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, profile) {
+		t.Errorf("loaded %+v, expected %+v", loaded, profile)
+	}
+
+	if err := (Profile{}).Save(dir); err == nil {
+		t.Errorf("profile without serial was saved")
+	}
+	if _, err := LoadProfile(dir, "unknown"); !os.IsNotExist(errors.Cause(err)) {
+		t.Errorf("missing profile returned %v", err)
+	}
+}
@@ -8,92 +8,117 @@ import (
 	"github.com/draeron/gopkgs/color/7bits"
 )
 
+// SetDial shows a number in [-99,99] on the seven segment display, see NumberDisplay.
 func (d *Device) SetDial(val int8) error {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
+	return d.update(func(out *OutState) error {
+		return out.setDial(val)
+	})
+}
 
-	d.state.out.SevenSegment = val
+func (d *Device) SetBrightness(btn button.Button, val uint8) error {
+	return d.update(func(out *OutState) error {
+		return out.setBrightness(btn, val)
+	})
+}
 
-	err := d.state.out.Write(d.device)
-	return errors.WithMessage(err, "failed to write to HID device")
+/*
+	SetMuteBrightness sets the two LEDs of a mute key independently, SetBrightness sets both to the same value.
+*/
+func (d *Device) SetMuteBrightness(btn button.Button, first, second uint8) error {
+	return d.update(func(out *OutState) error {
+		return out.setMuteBrightness(btn, first, second)
+	})
 }
 
-func (d *Device) SetBrightness(btn button.Button, val uint8) error {
+func (d *Device) SetPadColorAll(col color.Color) error {
+	return d.SetPadColorMany(button.Pads(), col)
+}
+
+func (d *Device) SetPadColorMany(btns []button.Button, col color.Color) error {
+	return d.SetPadColors(padColorMany(btns, col))
+}
+
+func (d *Device) SetPadColor(btn button.Button, col color.Color) error {
+	return d.update(func(out *OutState) error {
+		return out.setPadColor(btn, col)
+	})
+}
+
+func (d *Device) SetPadColors(mapp button.ColorMap) error {
+	return d.update(func(out *OutState) error {
+		return out.setPadColors(mapp)
+	})
+}
+
+/*
+	update applies the modification to a copy of the output state, the copy replaces the
+	current state and is sent to the device only if no error occurred.
+*/
+func (d *Device) update(fn func(out *OutState) error) error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	if btn.IsPad() || btn.IsKnob() || btn.IsFader() {
-		return errors.Errorf("button %v is cannot set on")
+	out := d.state.out.Copy()
+	err := fn(&out)
+	if err != nil {
+		return err
 	}
+	d.state.out = out
 
+	return d.write()
+}
+
+func (o *OutState) setDial(val int8) error {
+	o.Display = NumberDisplay(val)
+	return nil
+}
+
+func (o *OutState) setBrightness(btn button.Button, val uint8) error {
 	bright := LEDIntensity(val)
 
 	switch {
 	case btn.IsMute():
 		idx := btn - button.Mute1
-		d.state.out.Mute[idx] = bright
+		o.Mute[idx] = MuteLEDs{bright, bright}
 	case btn.IsFunctions():
-		d.state.out.Functions[btn] = bright
+		o.Functions[btn] = bright
+	default:
+		return errors.Errorf("button %v brightness cannot be set", btn)
 	}
-
-	err := d.state.out.Write(d.device)
-	return errors.WithMessage(err, "failed to write to HID device")
+	return nil
 }
 
-func (d *Device) SetPadColorAll(col color.Color) error {
-	return d.SetPadColorMany(button.Values(), col)
-}
-
-func (d *Device) SetPadColorMany(btns []button.Button, col color.Color) error {
-	mapp := button.ColorMap{}
-	t := seven_bits.FromColor(col)
-	for _, b := range btns {
-		mapp[b] = t
+func (o *OutState) setMuteBrightness(btn button.Button, first, second uint8) error {
+	if !btn.IsMute() {
+		return errors.Errorf("button %v is not a mute", btn)
 	}
-	return d.SetPadColors(mapp)
+	o.Mute[btn-button.Mute1] = MuteLEDs{LEDIntensity(first), LEDIntensity(second)}
+	return nil
 }
 
-func (d *Device) SetPadColor(btn button.Button, col color.Color) error {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
-
-	if btn.IsPad() {
-		d.state.out.Pads[btn-button.PadA1] = col
-	} else {
+func (o *OutState) setPadColor(btn button.Button, col color.Color) error {
+	if !btn.IsPad() {
 		return errors.Errorf("button %v is not a pad", btn)
 	}
-
-	err := d.state.out.Write(d.device)
-	return errors.WithMessage(err, "failed to write to HID device")
+	o.Pads[btn-button.PadA1] = col
+	return nil
 }
 
-func (d *Device) SetPadColors(mapp button.ColorMap) error {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
-
+func (o *OutState) setPadColors(mapp button.ColorMap) error {
 	for btn, col := range mapp {
-		if btn.IsPad() {
-			d.state.out.Pads[btn-button.PadA1] = col
-		} else {
-			return errors.Errorf("button %v is not a pad", btn)
+		err := o.setPadColor(btn, col)
+		if err != nil {
+			return err
 		}
 	}
-
-	err := d.state.out.Write(d.device)
-	return errors.WithMessage(err, "failed to write to HID device")
+	return nil
 }
 
-// func (c *Device) SetPadColor(btn button.Button, color color.Color) error {
-// 	c.mutex.Lock()
-// 	defer c.mutex.Unlock()
-//
-// 	if !btn.IsPad() {
-// 		return errors.Errorf("button %v is not a pad button", btn)
-// 	}
-//
-// 	idx := btn - button.PadA1
-// 	c.state.out.Pads[idx] = color
-//
-// 	err := c.state.out.Write(c.device)
-// 	return errors.WithMessage(err, "failed to write to HID device")
-// }
+func padColorMany(btns []button.Button, col color.Color) button.ColorMap {
+	mapp := button.ColorMap{}
+	t := seven_bits.FromColor(col)
+	for _, b := range btns {
+		mapp[b] = t
+	}
+	return mapp
+}
@@ -5,27 +5,37 @@ import (
 )
 
 func (d *Device) EnableDebugLogger() {
+	log.Debugf("enable debug logging of events")
+	ch := make(chan event.Event, 20)
+	d.subscribe(ch, SubscriptionOptions{}, true)
+
+	d.wg.Add(1)
 	go func() {
-		log.Debugf("enable debug logging of events")
-		ch := make(chan event.Event, 20)
-		d.Subscribe(ch)
+		defer d.wg.Done()
 		for evt := range ch {
 			log.Debugf(evt.String())
 		}
 	}()
 }
 
-func (d *Device) Subscribe(channel chan<- event.Event) {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
-	log.Infof("adding new event suscriber")
-	d.subscribers = append(d.subscribers, channel)
+/*
+	Close shuts the device down and waits for every internal goroutine to exit, it must not be called
+	from a callback registered with AddCallback.
+*/
+func (d *Device) Close() {
+	d.cancel()
+	<-d.done
+	d.wg.Wait()
 }
 
-func (d *Device) Close() {
-	if d.device != nil {
-		d.device.Close()
-	}
+// Done returns a channel closed once the device is shut down.
+func (d *Device) Done() <-chan struct{} {
+	return d.done
+}
+
+// Descriptor returns the USB descriptor of the opened F1, it is empty for custom transports.
+func (d *Device) Descriptor() Descriptor {
+	return d.descriptor
 }
 
 func (d *Device) Name() string {
@@ -1,108 +1,206 @@
 package device
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
-
-	"github.com/bearsh/hid"
+	"go.uber.org/atomic"
 
 	"github.com/draeron/gof1/pkg/f1/event"
 )
 
 type Device struct {
-	device      *hid.Device
-	subscribers []chan<- event.Event
+	transport   Transport
+	descriptor  Descriptor
+	subscribers []*Subscription
 	state       State
 	mutex       sync.RWMutex
+
+	opener            Opener
+	reconnectInterval time.Duration
+	connected         bool
+
+	frameInterval time.Duration
+	frameTimer    *time.Timer
+	lastWrite     time.Time
+	dirty         bool
+	outputStack   []OutState
+
+	analog     [analogCount]analogChannel
+	calibrator *Calibrator
+	dial       dialState
+	sequence   atomic.Uint64
+	groups     atomic.Uint64
+	publishing sync.Mutex // serializes the sequence numbering and the delivery of events
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}  // closed once the device is shut down
+	wg     sync.WaitGroup // internal goroutines
 }
 
 const F1ProductName = "Traktor Kontrol F1"
 
-func Open() (*Device, error) {
+// OpenOption configures a device when it's opened, before its input is read.
+type OpenOption func(d *Device) error
+
+/*
+	OpenTransport creates a Device on top of an already opened transport, the device takes ownership
+	of the transport and will close it when closed.
+*/
+func OpenTransport(transport Transport, options ...OpenOption) (*Device, error) {
+	return OpenTransportContext(context.Background(), transport, options...)
+}
 
-	if !hid.Supported() {
-		return nil, errors.New("HID USB operations not supported on this platform")
+/*
+	OpenTransportContext is like OpenTransport, the device is shut down when the context is cancelled.
+*/
+func OpenTransportContext(ctx context.Context, transport Transport, options ...OpenOption) (*Device, error) {
+	return open(ctx, transport, Descriptor{Product: F1ProductName}, nil, options)
+}
+
+func open(ctx context.Context, transport Transport, desc Descriptor, opener Opener, options []OpenOption) (*Device, error) {
+	if transport == nil {
+		return nil, errors.New("transport cannot be nil")
 	}
 
-	var err error
+	ctx, cancel := context.WithCancel(ctx)
+
 	ctrl := &Device{
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+		transport:  transport,
+		descriptor: desc,
+		opener:     opener,
+		connected:  true,
 		state: State{
 			out: NewOutState(),
 			in:  InState{},
 		},
 	}
 
-	var selected *hid.DeviceInfo
-	for _, devinfo := range hid.Enumerate(6092, 4384) {
-		jinfo, _ := json.MarshalIndent(devinfo, "", "  ")
-		log.Infof("info: \n%v", string(jinfo))
-
-		if devinfo.Product != F1ProductName {
-			log.Warnf("usb product name '%s' is not equal to '%s'", F1ProductName)
-		}
-
-		selected = &devinfo
-
+	for idx := range ctrl.analog {
+		ctrl.analog[idx].filter = DefaultAnalogFilter
 	}
 
-	if selected == nil {
-		return nil, errors.New("no F1 controller were found")
-	}
+	ctrl.loadDefaultProfile()
 
-	ctrl.device, err = selected.Open()
-	if err != nil {
-		return nil, errors.WithMessage(err, "failed to open Traktor F1 HID device")
+	for _, option := range options {
+		if err := option(ctrl); err != nil {
+			cancel()
+			transport.Close()
+			return nil, err
+		}
 	}
 
-	log.Infof("opened device: %v", ctrl.device)
+	log.Infof("opened device: %v", transport)
 
-	err = ctrl.state.out.Write(ctrl.device)
+	err := ctrl.writeNow()
 	if err != nil {
 		log.Errorf("failed to init HID state: %+v", err)
 	}
 
-	go ctrl.processInput()
+	reading := make(chan struct{})
+	ctrl.wg.Add(1)
+	go func() {
+		defer ctrl.wg.Done()
+		defer close(reading)
+		ctrl.processInput()
+	}()
+
+	go ctrl.shutdown(reading)
 
 	return ctrl, nil
 }
 
+/*
+	shutdown waits for the context to be done, then stops the reader and closes every subscription.
+*/
+func (d *Device) shutdown(reading <-chan struct{}) {
+	<-d.ctx.Done()
+
+	d.mutex.Lock()
+	d.flushFrame()
+	d.connected = false
+	if d.transport != nil {
+		d.transport.Close()
+	}
+	d.mutex.Unlock()
+
+	<-reading
+
+	d.mutex.Lock()
+	for _, sub := range d.subscribers {
+		sub.shutdown()
+	}
+	d.subscribers = nil
+	d.mutex.Unlock()
+
+	log.Infof("device %v was shut down", d)
+	close(d.done)
+}
+
 func (d *Device) processInput() {
 	log.Infof("starting to read from HID device")
 	defer log.Infof("stopped reading from HID device")
 
 	first := true
 
-	buffer := make([]byte, 22)
-	for {
-		length, err := d.device.Read(buffer)
+	d.mutex.RLock()
+	transport := d.transport
+	d.mutex.RUnlock()
+
+	buffer := make([]byte, InReportSize)
+	for d.ctx.Err() == nil {
+		length, err := transport.Read(buffer)
 		if err != nil {
-			log.Errorf("failed to read buffer from HID device")
-			return
+			transport = d.reconnect(transport, err)
+			if transport == nil {
+				return
+			}
+			first = true
 		} else if length > 0 {
+			now := time.Now()
 			current := NewInState()
 
-			err = current.UnpackPacket(bytes.NewReader(buffer))
+			err = current.UnmarshalBinary(buffer[:length])
 			if err != nil {
-				log.Errorf("failed to parse HID packet")
+				log.Errorf("failed to parse HID packet: %v", err)
+				continue
 			}
 
-			previous := d.State()
+			// the arrays of readings are copied before being processed
+			raw := *current
+
+			d.mutex.Lock()
+			d.processAnalog(current)
+			previous := d.state.Copy()
+			d.mutex.Unlock()
 
-			// ignore value on first dial event
+			var events []event.Event
 			if first {
-				log.Infof("first received message is ignored")
-				previous.in.Dial = current.Dial
+				// the previous state is unknown, report where every control sits instead
+				events = current.initialEvents()
 				first = false
+			} else {
+				events = previous.eventFromDiff(*current)
 			}
 
-			events := previous.eventFromDiff(*current)
-
-			for _, evt := range events {
-				d.sendToSubscribers(evt)
+			d.mutex.Lock()
+			for idx := range events {
+				switch {
+				case events[idx].Type == event.Increment || events[idx].Type == event.Decrement:
+					d.dial.accelerate(&events[idx], now)
+				case events[idx].Btn.IsFader() || events[idx].Btn.IsKnob():
+					events[idx].Raw = raw.analog(events[idx].Btn)
+				}
 			}
+			d.mutex.Unlock()
+
+			d.emit(events, now)
 
 			// replace input state
 			d.mutex.Lock()
@@ -115,10 +213,10 @@ func (d *Device) processInput() {
 }
 
 /*
-	thread safe state retrieval
+	thread safe state retrieval, the returned snapshot can be freely kept and read
 */
 func (d *Device) State() State {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
 	return d.state.Copy()
 }
@@ -0,0 +1,99 @@
+package device
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/f1/event"
+)
+
+/*
+	DialAcceleration makes fast spins of the dial move further. When the dial turns faster than the threshold,
+	each step is multiplied by 1 + (speed - threshold) * factor, up to the maximum multiplier.
+	The zero value disables acceleration.
+*/
+type DialAcceleration struct {
+	// Speed, in steps per second, above which steps are accelerated
+	Threshold float64
+	// Multiplier added for each step per second above the threshold
+	Factor float64
+	// Upper bound of the multiplier, 0 for no bound
+	MaxMultiplier float64
+}
+
+// dialIdle is the delay after which the dial is considered to start turning again from rest
+const dialIdle = 250 * time.Millisecond
+
+type dialState struct {
+	acceleration DialAcceleration
+	position     int64
+	last         time.Time
+}
+
+/*
+	SetDialAcceleration configures the acceleration applied to the delta of dial events.
+*/
+func (d *Device) SetDialAcceleration(accel DialAcceleration) error {
+	if accel.Threshold < 0 || accel.Factor < 0 || accel.MaxMultiplier < 0 {
+		return errors.Errorf("dial acceleration cannot be negative: %+v", accel)
+	}
+	if accel.MaxMultiplier > 0 && accel.MaxMultiplier < 1 {
+		return errors.Errorf("dial acceleration maximum multiplier %v is below 1", accel.MaxMultiplier)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.dial.acceleration = accel
+	return nil
+}
+
+// DialPosition returns the accumulated position of the dial, the sum of the deltas of every dial event.
+func (d *Device) DialPosition() int64 {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.dial.position
+}
+
+// SetDialPosition sets the accumulated position of the dial.
+func (d *Device) SetDialPosition(position int64) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.dial.position = position
+}
+
+/*
+	accelerate applies the acceleration to the delta of a dial event and fills its position,
+	caller must hold the lock
+*/
+func (s *dialState) accelerate(evt *event.Event, now time.Time) {
+	steps := float64(evt.Delta)
+	multiplier := 1.0
+
+	elapsed := now.Sub(s.last)
+	if s.acceleration.Factor > 0 && !s.last.IsZero() && elapsed < dialIdle {
+		if elapsed < time.Millisecond {
+			elapsed = time.Millisecond
+		}
+		speed := math.Abs(steps) / elapsed.Seconds()
+		if speed > s.acceleration.Threshold {
+			multiplier += (speed - s.acceleration.Threshold) * s.acceleration.Factor
+		}
+		if s.acceleration.MaxMultiplier > 0 && multiplier > s.acceleration.MaxMultiplier {
+			multiplier = s.acceleration.MaxMultiplier
+		}
+	}
+	s.last = now
+
+	delta := math.Round(steps * multiplier)
+	if delta > math.MaxInt16 {
+		delta = math.MaxInt16
+	} else if delta < math.MinInt16 {
+		delta = math.MinInt16
+	}
+
+	evt.Delta = int16(delta)
+	s.position += int64(evt.Delta)
+	evt.Position = s.position
+}
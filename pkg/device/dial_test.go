@@ -0,0 +1,86 @@
+package device
+
+import (
+	"testing"
+	"time"
+
+	button2 "github.com/draeron/gof1/pkg/f1/button"
+	event2 "github.com/draeron/gof1/pkg/f1/event"
+)
+
+func TestDialDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous uint8
+		current  uint8
+		tp       event2.Type
+		delta    int16
+	}{
+		{"one step", 10, 11, event2.Increment, 1},
+		{"one step back", 10, 9, event2.Decrement, -1},
+		{"forward across 255", 254, 2, event2.Increment, 4},
+		{"backward across 0", 1, 253, event2.Decrement, -4},
+		{"forward to 0", 255, 0, event2.Increment, 1},
+		{"backward to 255", 0, 255, event2.Decrement, -1},
+		{"largest forward", 200, 71, event2.Increment, 127},
+		{"largest backward", 71, 199, event2.Decrement, -128},
+	}
+
+	for _, test := range tests {
+		state := State{in: *NewInState()}
+		state.in.Dial = test.previous
+		current := *NewInState()
+		current.Dial = test.current
+
+		evts := state.eventFromDiff(current)
+		if len(evts) != 1 {
+			t.Errorf("%s: got %d events", test.name, len(evts))
+			continue
+		}
+		evt := evts[0]
+		if evt.Btn != button2.Dial || evt.Type != test.tp || evt.Delta != test.delta || evt.Value != int16(test.current) {
+			t.Errorf("%s: got %v with a delta of %d, expected %v %d", test.name, evt, evt.Delta, test.tp, test.delta)
+		}
+	}
+}
+
+func TestDialAccelerate(t *testing.T) {
+	accel := DialAcceleration{Threshold: 10, Factor: 0.1, MaxMultiplier: 4}
+	start := time.Now()
+
+	tests := []struct {
+		name    string
+		accel   DialAcceleration
+		elapsed time.Duration // since the previous turn
+		steps   int16
+		delta   int16
+	}{
+		{"disabled", DialAcceleration{}, 10 * time.Millisecond, 3, 3},
+		{"below threshold", accel, 500 * time.Millisecond, 2, 2},
+		{"from rest", accel, dialIdle, 5, 5},
+		// 2 steps in 100ms is 20 steps/s, the multiplier is 1 + 10 * 0.1
+		{"accelerated", accel, 100 * time.Millisecond, 2, 4},
+		{"accelerated backward", accel, 100 * time.Millisecond, -2, -4},
+		// 2 steps in 10ms is 200 steps/s, the multiplier is capped
+		{"capped", accel, 10 * time.Millisecond, 2, 8},
+		{"capped backward", accel, 10 * time.Millisecond, -3, -12},
+		{"uncapped", DialAcceleration{Threshold: 10, Factor: 0.1}, 10 * time.Millisecond, 2, 40},
+		{"bounded by int16", DialAcceleration{Factor: 1000}, time.Millisecond, 100, 32767},
+	}
+
+	for _, test := range tests {
+		state := dialState{acceleration: test.accel, position: 100, last: start}
+		evt := event2.Event{Btn: button2.Dial, Type: event2.Increment, Delta: test.steps}
+
+		state.accelerate(&evt, start.Add(test.elapsed))
+		if evt.Delta != test.delta {
+			t.Errorf("%s: delta %d, expected %d", test.name, evt.Delta, test.delta)
+		}
+		if evt.Position != 100+int64(test.delta) || state.position != evt.Position {
+			t.Errorf("%s: position %d, expected %d", test.name, evt.Position, 100+int64(test.delta))
+		}
+		if !state.last.Equal(start.Add(test.elapsed)) {
+			t.Errorf("%s: time of the turn wasn't recorded", test.name)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package device
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Digit is the state of a single digit of the seven segment display
+type Digit struct {
+	Segments Segments
+	Dot      bool // the decimal point, appears top left of the digit
+}
+
+// Display is the state of the two digits seven segment display
+type Display struct {
+	Left  Digit
+	Right Digit
+}
+
+// SegmentMask is a bit mask of the segments of a digit, see SegA to SegG
+type SegmentMask uint8
+
+/*
+	Segments are named as usual:
+
+		 AAA
+		F   B
+		 GGG
+		E   C
+		 DDD
+*/
+const (
+	SegA SegmentMask = 1 << iota
+	SegB
+	SegC
+	SegD
+	SegE
+	SegF
+	SegG
+)
+
+// order of the segments in the output report
+var segmentsOrder = [7]SegmentMask{SegG, SegC, SegB, SegA, SegF, SegE, SegD}
+
+func (m SegmentMask) Segments() (s Segments) {
+	for idx, seg := range segmentsOrder {
+		if m&seg != 0 {
+			s[idx] = On
+		}
+	}
+	return
+}
+
+func (s Segments) Mask() (m SegmentMask) {
+	for idx, seg := range segmentsOrder {
+		if s[idx] != Off {
+			m |= seg
+		}
+	}
+	return
+}
+
+/*
+	GlyphSegmentMapping contains every character which can be shown on a digit. Every letter has an
+	approximation, in upper or lower case depending on which one fits best on 7 segments, the other case
+	is accepted as a fallback. Some letters share the shape of another one (V and U, Z and 2). Digits
+	are added from NumberSegmentMapping so numbers and text always show the same shapes.
+*/
+var GlyphSegmentMapping = map[rune]SegmentMask{
+	'A': SegA | SegB | SegC | SegE | SegF | SegG,
+	'b': SegC | SegD | SegE | SegF | SegG,
+	'C': SegA | SegD | SegE | SegF,
+	'c': SegD | SegE | SegG,
+	'd': SegB | SegC | SegD | SegE | SegG,
+	'E': SegA | SegD | SegE | SegF | SegG,
+	'F': SegA | SegE | SegF | SegG,
+	'G': SegA | SegC | SegD | SegE | SegF,
+	'H': SegB | SegC | SegE | SegF | SegG,
+	'h': SegC | SegE | SegF | SegG,
+	'I': SegE | SegF,
+	'i': SegC,
+	'J': SegB | SegC | SegD | SegE,
+	'K': SegA | SegC | SegE | SegF | SegG,
+	'L': SegD | SegE | SegF,
+	'M': SegA | SegC | SegE,
+	'N': SegA | SegB | SegC | SegE | SegF,
+	'n': SegC | SegE | SegG,
+	'O': SegA | SegB | SegC | SegD | SegE | SegF,
+	'o': SegC | SegD | SegE | SegG,
+	'P': SegA | SegB | SegE | SegF | SegG,
+	'q': SegA | SegB | SegC | SegF | SegG,
+	'r': SegE | SegG,
+	'S': SegA | SegC | SegD | SegF | SegG,
+	't': SegD | SegE | SegF | SegG,
+	'U': SegB | SegC | SegD | SegE | SegF,
+	'u': SegC | SegD | SegE,
+	'V': SegB | SegC | SegD | SegE | SegF,
+	'v': SegC | SegD | SegE,
+	'W': SegB | SegD | SegF,
+	'x': SegB | SegC | SegE | SegF | SegG,
+	'y': SegB | SegC | SegD | SegF | SegG,
+	'Z': SegA | SegB | SegD | SegE | SegG,
+
+	' ':  0,
+	'-':  SegG,
+	'_':  SegD,
+	'=':  SegD | SegG,
+	'\'': SegF,
+	'"':  SegB | SegF,
+}
+
+func init() {
+	for val, seg := range NumberSegmentMapping {
+		GlyphSegmentMapping['0'+rune(val)] = seg.Mask()
+	}
+}
+
+// Glyph returns the segments showing the character.
+func Glyph(char rune) (Segments, error) {
+	if mask, ok := GlyphSegmentMapping[char]; ok {
+		return mask.Segments(), nil
+	}
+	if mask, ok := GlyphSegmentMapping[unicode.ToLower(char)]; ok {
+		return mask.Segments(), nil
+	}
+	if mask, ok := GlyphSegmentMapping[unicode.ToUpper(char)]; ok {
+		return mask.Segments(), nil
+	}
+	return Segments{}, &ErrUnknownGlyph{Glyph: char}
+}
+
+/*
+	NumberDisplay shows a number in [-99,99], a negative number turns on both decimal points.
+*/
+func NumberDisplay(val int8) Display {
+	absolute := int(val)
+	if absolute < 0 {
+		absolute = -absolute
+	}
+	if absolute > 99 {
+		absolute = 99
+	}
+
+	return Display{
+		Left: Digit{
+			Segments: NumberSegmentMapping[int8(absolute/10)],
+			Dot:      val < 0,
+		},
+		Right: Digit{
+			Segments: NumberSegmentMapping[int8(absolute%10)],
+			Dot:      val < 0,
+		},
+	}
+}
+
+/*
+	TextDisplay shows up to two characters, a single character is shown on the right digit.
+*/
+func TextDisplay(text string) (Display, error) {
+	chars := []rune(text)
+	if len(chars) > 2 {
+		return Display{}, errors.Errorf("text '%s' is longer than 2 characters", text)
+	}
+	for len(chars) < 2 {
+		chars = append([]rune{' '}, chars...)
+	}
+
+	left, err := Glyph(chars[0])
+	if err != nil {
+		return Display{}, err
+	}
+	right, err := Glyph(chars[1])
+	if err != nil {
+		return Display{}, err
+	}
+
+	return Display{
+		Left:  Digit{Segments: left},
+		Right: Digit{Segments: right},
+	}, nil
+}
+
+// HexDisplay shows a byte as two hexadecimal digits.
+func HexDisplay(val uint8) Display {
+	// every hexadecimal digit has a glyph
+	display, _ := TextDisplay(fmt.Sprintf("%02X", val))
+	return display
+}
+
+/*
+	Number decodes the display as shown by NumberDisplay, false is returned if it doesn't show a number.
+*/
+func (d Display) Number() (int8, bool) {
+	tens, ok := d.Left.number()
+	if !ok {
+		return 0, false
+	}
+	units, ok := d.Right.number()
+	if !ok {
+		return 0, false
+	}
+
+	val := tens*10 + units
+	if d.Left.Dot && d.Right.Dot {
+		val = -val
+	}
+	return val, true
+}
+
+/*
+	Text decodes the display into characters, '?' is used for segments not matching any glyph.
+*/
+func (d Display) Text() string {
+	return string([]rune{d.Left.glyph(), d.Right.glyph()})
+}
+
+func (d Digit) number() (int8, bool) {
+	for val, seg := range NumberSegmentMapping {
+		if seg == d.Segments {
+			return val, true
+		}
+	}
+	return 0, false
+}
+
+func (d Digit) glyph() rune {
+	mask := d.Segments.Mask()
+
+	// prefer digits, then the first matching glyph in a stable order
+	if val, ok := d.number(); ok {
+		return rune('0' + val)
+	}
+	found := '?'
+	for char, it := range GlyphSegmentMapping {
+		if it == mask && (found == '?' || char < found) {
+			found = char
+		}
+	}
+	return found
+}
+
+func (d Digit) dotValue() byte {
+	if d.Dot {
+		return On
+	}
+	return Off
+}
+
+func unpackDigit(data []byte) (digit Digit) {
+	digit.Dot = data[0] != Off
+	copy(digit.Segments[:], data[1:8])
+	return
+}
+
+/*
+	Device API
+*/
+
+// SetDisplay replaces the whole content of the seven segment display.
+func (d *Device) SetDisplay(display Display) error {
+	return d.update(func(out *OutState) error {
+		return out.setDisplay(display)
+	})
+}
+
+// SetDisplayText shows up to two characters, see TextDisplay.
+func (d *Device) SetDisplayText(text string) error {
+	display, err := TextDisplay(text)
+	if err != nil {
+		return err
+	}
+	return d.SetDisplay(display)
+}
+
+// SetDisplayHex shows a byte as two hexadecimal digits.
+func (d *Device) SetDisplayHex(val uint8) error {
+	return d.SetDisplay(HexDisplay(val))
+}
+
+// SetDisplaySegments sets the raw segments of both digits, decimal points are left unchanged.
+func (d *Device) SetDisplaySegments(left, right Segments) error {
+	return d.update(func(out *OutState) error {
+		return out.setDisplaySegments(left, right)
+	})
+}
+
+// SetDisplayDots sets the decimal points of both digits, segments are left unchanged.
+func (d *Device) SetDisplayDots(left, right bool) error {
+	return d.update(func(out *OutState) error {
+		return out.setDisplayDots(left, right)
+	})
+}
+
+func (o *OutState) setDisplay(display Display) error {
+	o.Display = display
+	return nil
+}
+
+func (o *OutState) setDisplaySegments(left, right Segments) error {
+	o.Display.Left.Segments = left
+	o.Display.Right.Segments = right
+	return nil
+}
+
+func (o *OutState) setDisplayDots(left, right bool) error {
+	o.Display.Left.Dot = left
+	o.Display.Right.Dot = right
+	return nil
+}
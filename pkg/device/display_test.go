@@ -0,0 +1,79 @@
+package device
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGlyphLetters(t *testing.T) {
+	for char := 'a'; char <= 'z'; char++ {
+		for _, glyph := range []rune{char, char - 'a' + 'A'} {
+			segments, err := Glyph(glyph)
+			if err != nil {
+				t.Errorf("letter %c: %v", glyph, err)
+			} else if segments.Mask() == 0 {
+				t.Errorf("letter %c has no segment", glyph)
+			}
+		}
+	}
+}
+
+// TestGlyphNumbers checks a number shown as text reads back as the same number
+func TestGlyphNumbers(t *testing.T) {
+	for val := int8(-99); val <= 99; val++ {
+		number := NumberDisplay(val)
+		text, err := TextDisplay(fmt.Sprintf("%02d", abs(val)))
+		if err != nil {
+			t.Fatalf("%d: %v", val, err)
+		}
+		if number.Left.Segments != text.Left.Segments || number.Right.Segments != text.Right.Segments {
+			t.Errorf("%d is not shown as its text", val)
+		}
+		if got, ok := text.Number(); !ok || got != abs(val) {
+			t.Errorf("text of %d reads back as %d", val, got)
+		}
+	}
+}
+
+func abs(val int8) int8 {
+	if val < 0 {
+		return -val
+	}
+	return val
+}
+
+func TestTextDisplay(t *testing.T) {
+	tests := []struct {
+		text  string
+		left  rune
+		right rune
+	}{
+		{"Fx", 'F', 'x'},
+		{"bA", 'b', 'A'},
+		{"--", '-', '-'},
+		{"k", ' ', 'k'},
+		{"VM", 'V', 'M'},
+	}
+
+	for _, test := range tests {
+		display, err := TextDisplay(test.text)
+		if err != nil {
+			t.Errorf("%q: %v", test.text, err)
+			continue
+		}
+		left, _ := Glyph(test.left)
+		right, _ := Glyph(test.right)
+		if display.Left.Segments != left || display.Right.Segments != right {
+			t.Errorf("%q: got %v %v", test.text, display.Left.Segments, display.Right.Segments)
+		}
+	}
+
+	_, err := TextDisplay("abc")
+	if err == nil {
+		t.Errorf("text longer than 2 characters should fail")
+	}
+	_, err = TextDisplay("#")
+	if _, ok := err.(*ErrUnknownGlyph); !ok {
+		t.Errorf("expected ErrUnknownGlyph, got %v", err)
+	}
+}
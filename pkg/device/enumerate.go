@@ -0,0 +1,204 @@
+package device
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bearsh/hid"
+	"github.com/pkg/errors"
+)
+
+// USB identifiers of the Traktor Kontrol F1
+const (
+	VendorID  = 6092
+	ProductID = 4384
+)
+
+// Descriptor identifies a F1 connected to the host
+type Descriptor struct {
+	Serial  string // USB serial number, stable across reconnections
+	Path    string // platform specific device path, may change when replugged
+	Product string
+	Release uint16
+}
+
+func (d Descriptor) String() string {
+	return fmt.Sprintf("%s (serial: %s, path: %s)", d.Product, d.Serial, d.Path)
+}
+
+/*
+	Enumerate lists every F1 currently connected to the host.
+*/
+func Enumerate() ([]Descriptor, error) {
+	if !hid.Supported() {
+		return nil, errors.New("HID USB operations not supported on this platform")
+	}
+
+	descs := []Descriptor{}
+	for _, devinfo := range hid.Enumerate(VendorID, ProductID) {
+		jinfo, _ := json.MarshalIndent(devinfo, "", "  ")
+		log.Debugf("info: \n%v", string(jinfo))
+
+		if devinfo.Product != F1ProductName {
+			log.Warnf("usb product name '%s' is not equal to '%s'", devinfo.Product, F1ProductName)
+		}
+
+		descs = append(descs, Descriptor{
+			Serial:  devinfo.Serial,
+			Path:    devinfo.Path,
+			Product: devinfo.Product,
+			Release: devinfo.Release,
+		})
+	}
+	return descs, nil
+}
+
+/*
+	Open opens the first F1 found, use OpenBySerial or OpenByPath when multiple units are connected.
+*/
+func Open(options ...OpenOption) (*Device, error) {
+	return OpenContext(context.Background(), options...)
+}
+
+/*
+	OpenContext is like Open, the device is shut down when the context is cancelled.
+*/
+func OpenContext(ctx context.Context, options ...OpenOption) (*Device, error) {
+	descs, err := Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	if len(descs) == 0 {
+		return nil, errors.New("no F1 controller were found")
+	}
+	if len(descs) > 1 {
+		log.Warnf("%d F1 controllers were found, opening %v", len(descs), descs[0])
+	}
+	return descs[0].OpenContext(ctx, options...)
+}
+
+/*
+	OpenBySerial opens the F1 with the USB serial number, the serial is stable across reconnections.
+*/
+func OpenBySerial(serial string, options ...OpenOption) (*Device, error) {
+	return OpenBySerialContext(context.Background(), serial, options...)
+}
+
+// OpenBySerialContext is like OpenBySerial, the device is shut down when the context is cancelled.
+func OpenBySerialContext(ctx context.Context, serial string, options ...OpenOption) (*Device, error) {
+	desc, err := find(func(d Descriptor) bool { return d.Serial == serial })
+	if err != nil {
+		return nil, errors.WithMessagef(err, "no F1 controller with serial '%s'", serial)
+	}
+	return desc.OpenContext(ctx, options...)
+}
+
+/*
+	OpenByPath opens the F1 with the platform specific device path.
+*/
+func OpenByPath(path string, options ...OpenOption) (*Device, error) {
+	return OpenByPathContext(context.Background(), path, options...)
+}
+
+// OpenByPathContext is like OpenByPath, the device is shut down when the context is cancelled.
+func OpenByPathContext(ctx context.Context, path string, options ...OpenOption) (*Device, error) {
+	desc, err := find(func(d Descriptor) bool { return d.Path == path })
+	if err != nil {
+		return nil, errors.WithMessagef(err, "no F1 controller with path '%s'", path)
+	}
+	return desc.OpenContext(ctx, options...)
+}
+
+func (d Descriptor) Open(options ...OpenOption) (*Device, error) {
+	return d.OpenContext(context.Background(), options...)
+}
+
+func (d Descriptor) OpenContext(ctx context.Context, options ...OpenOption) (*Device, error) {
+	transport, err := d.openTransport()
+	if err != nil {
+		return nil, err
+	}
+	return open(ctx, transport, d, d.reopen, options)
+}
+
+/*
+	OpenWrapped opens this unit with every transport wrapped, including the ones reopened when supervised,
+	e.g. by capture.Wrapper.
+*/
+func (d Descriptor) OpenWrapped(wrap func(Transport) Transport, options ...OpenOption) (*Device, error) {
+	return d.OpenWrappedContext(context.Background(), wrap, options...)
+}
+
+// OpenWrappedContext is like OpenWrapped, the device is shut down when the context is cancelled.
+func (d Descriptor) OpenWrappedContext(ctx context.Context, wrap func(Transport) Transport, options ...OpenOption) (*Device, error) {
+	if wrap == nil {
+		return nil, errors.New("wrap cannot be nil")
+	}
+
+	opener := func() (Transport, error) {
+		transport, err := d.reopen()
+		if err != nil {
+			return nil, err
+		}
+		return wrap(transport), nil
+	}
+
+	transport, err := d.openTransport()
+	if err != nil {
+		return nil, err
+	}
+	return open(ctx, wrap(transport), d, opener, options)
+}
+
+func (d Descriptor) openTransport() (Transport, error) {
+	device, err := hid.DeviceInfo{
+		Path:      d.Path,
+		VendorID:  VendorID,
+		ProductID: ProductID,
+		Release:   d.Release,
+		Serial:    d.Serial,
+		Product:   d.Product,
+	}.Open()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open Traktor F1 HID device")
+	}
+
+	return &hidTransport{device: device}, nil
+}
+
+/*
+	Opener returns an Opener finding and opening this unit, e.g. to test a unit transport before opening
+	it. A device should be opened from the descriptor itself so its serial is known and its calibration
+	profile loaded, use OpenWrapped to wrap its transport.
+*/
+func (d Descriptor) Opener() Opener {
+	return d.reopen
+}
+
+// reopen looks for the same unit, by serial if it has one since the path may change when replugged
+func (d Descriptor) reopen() (Transport, error) {
+	desc, err := find(func(it Descriptor) bool {
+		if d.Serial != "" {
+			return it.Serial == d.Serial
+		}
+		return it.Path == d.Path
+	})
+	if err != nil {
+		return nil, err
+	}
+	return desc.openTransport()
+}
+
+func find(match func(d Descriptor) bool) (Descriptor, error) {
+	descs, err := Enumerate()
+	if err != nil {
+		return Descriptor{}, err
+	}
+	for _, desc := range descs {
+		if match(desc) {
+			return desc, nil
+		}
+	}
+	return Descriptor{}, errors.New("device not found")
+}
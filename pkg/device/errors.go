@@ -0,0 +1,36 @@
+package device
+
+import (
+	"fmt"
+)
+
+// ErrReportLength is returned when a HID report doesn't have the size defined by the protocol
+type ErrReportLength struct {
+	Report   string
+	Expected int
+	Actual   int
+}
+
+func (e *ErrReportLength) Error() string {
+	return fmt.Sprintf("wrong %s report size, current: %v, spec: %v", e.Report, e.Actual, e.Expected)
+}
+
+// ErrReportVersion is returned when a HID report has an unknown version or id
+type ErrReportVersion struct {
+	Report   string
+	Expected byte
+	Actual   byte
+}
+
+func (e *ErrReportVersion) Error() string {
+	return fmt.Sprintf("wrong %s report version, current: %#x, spec: %#x", e.Report, e.Actual, e.Expected)
+}
+
+// ErrUnknownGlyph is returned when a character cannot be shown on the seven segment display
+type ErrUnknownGlyph struct {
+	Glyph rune
+}
+
+func (e *ErrUnknownGlyph) Error() string {
+	return fmt.Sprintf("character '%c' cannot be shown on the seven segment display", e.Glyph)
+}
@@ -0,0 +1,51 @@
+package device_test
+
+import (
+	"testing"
+
+	"github.com/draeron/gof1/pkg/device"
+	"github.com/draeron/gof1/pkg/f1/button"
+	"github.com/draeron/gof1/pkg/f1/event"
+	"github.com/draeron/gof1/pkg/virtual"
+)
+
+func TestAnalogReadings(t *testing.T) {
+	f1 := virtual.New()
+	dev, err := device.OpenTransport(f1)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(dev.Close)
+
+	err = dev.SetAnalogFilter(button.Volume2, device.AnalogFilter{DeadZone: 100})
+	if err != nil {
+		t.Fatalf("filter: %v", err)
+	}
+
+	events := make(chan event.Event, 100)
+	dev.Subscribe(events)
+
+	tests := []struct {
+		raw     uint16
+		reading uint16
+	}{
+		{2000, 2000},
+		{50, 0}, // snapped to the end stop by the dead zone
+		{device.AnalogMax - 10, device.AnalogMax},
+	}
+
+	for _, test := range tests {
+		if err := f1.MoveFader(button.Volume2, test.raw); err != nil {
+			t.Fatalf("move: %v", err)
+		}
+		evt := expect(t, events, func(evt event.Event) bool {
+			return evt.Btn == button.Volume2 && evt.Raw == test.raw
+		})
+		if evt.Reading != test.reading {
+			t.Errorf("raw %d was processed as %d, expected %d", test.raw, evt.Reading, test.reading)
+		}
+		if evt.Normalized != float64(test.reading)/device.AnalogMax {
+			t.Errorf("raw %d normalized as %v", test.raw, evt.Normalized)
+		}
+	}
+}
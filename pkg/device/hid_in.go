@@ -27,23 +27,30 @@ func NewInState() *InState {
 	return in
 }
 
+// Copy returns a deep copy of the state
+func (i InState) Copy() InState {
+	cpy := i
+	cpy.PressedButtons = map[button2.Button]button2.PushState{}
+	for k, v := range i.PressedButtons {
+		cpy.PressedButtons[k] = v
+	}
+	return cpy
+}
+
+// InReportSize is the length in bytes of an input report
+const InReportSize = 22
+
+// InReportVersion is the only known version of input report
+const InReportVersion = 0x01
+
+// AnalogMax is the highest value of the 12 bits ADC of the faders and knobs
+const AnalogMax = 0x0FFF
+
 /*
 	The state of all input controls is communicated via a single input report of 22 Bytes
 	The first byte is the version number, currently 0x01
 */
-func (packet *InState) UnpackPacket(rdr io.Reader) error {
-	var err error
-
-	err = binary.Read(rdr, binary.LittleEndian, &packet.Version)
-	if err != nil {
-		return errors.WithMessage(err, "failed to read HID packet")
-	}
-	if packet.Version != 0x1 {
-		log.Warnf("received a HID packet with invalid version")
-	}
-
-	sbyte := byte(0)
-
+var inputButtonBits = [4][]button2.Button{
 	// The next two byte contain the bit encoded boolean state of the pads, true = pressed.
 	/*
 		Byte 2 Bit 7 (MSB) = Pad 1
@@ -55,11 +62,7 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 		Byte 2 Bit 1       = Pad 7
 		Byte 2 Bit 0       = Pad 8
 	*/
-	err = binary.Read(rdr, binary.BigEndian, &sbyte)
-	if err != nil {
-		return errors.WithMessage(err, "failed to read HID packet")
-	}
-	packet.unpackbools(sbyte, []button2.Button{
+	{
 		button2.PadA1,
 		button2.PadA2,
 		button2.PadA3,
@@ -68,8 +71,7 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 		button2.PadB2,
 		button2.PadB3,
 		button2.PadB4,
-	})
-
+	},
 	/*
 		Byte 3 Bit 7 (MSB) = Pad 9
 		Byte 3 Bit 6       = Pad 10
@@ -80,11 +82,7 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 		Byte 3 Bit 1       = Pad 15
 		Byte 3 Bit 0       = Pad 16
 	*/
-	err = binary.Read(rdr, binary.BigEndian, &sbyte)
-	if err != nil {
-		return errors.WithMessage(err, "failed to read HID packet")
-	}
-	packet.unpackbools(sbyte, []button2.Button{
+	{
 		button2.PadC1,
 		button2.PadC2,
 		button2.PadC3,
@@ -93,8 +91,7 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 		button2.PadD2,
 		button2.PadD3,
 		button2.PadD4,
-	})
-
+	},
 	// The boolean state for the other buttons are sent via Byte 4 & Byte 5.
 	/*
 		Byte 4 Bit 7 (MSB) = Shift Key
@@ -102,23 +99,18 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 		Byte 4 Bit 5       = Type Key
 		Byte 4 Bit 4       = Size Key
 		Byte 4 Bit 3       = Browse Key
-		Byte 4 Bit 2       =
+		Byte 4 Bit 2       = Dial Push
 		Byte 4 Bit 1       =
 		Byte 4 Bit 0       =
 	*/
-	err = binary.Read(rdr, binary.BigEndian, &sbyte)
-	if err != nil {
-		return errors.WithMessage(err, "failed to read HID packet")
-	}
-	packet.unpackbools(sbyte, []button2.Button{
+	{
 		button2.Shift,
 		button2.Reverse,
 		button2.Type,
 		button2.Size,
 		button2.Browse,
 		button2.Dial,
-	})
-
+	},
 	/*
 		Byte 5 Bit 7 (MSB) = Kill Key 1
 		Byte 5 Bit 6            = Kill Key 2
@@ -129,11 +121,7 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 		Byte 5 Bit 1            = Capture Key
 		Byte 5 Bit 0            =
 	*/
-	err = binary.Read(rdr, binary.BigEndian, &sbyte)
-	if err != nil {
-		return errors.WithMessage(err, "failed to read HID packet")
-	}
-	packet.unpackbools(sbyte, []button2.Button{
+	{
 		button2.Mute1,
 		button2.Mute2,
 		button2.Mute3,
@@ -141,7 +129,41 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 		button2.Sync,
 		button2.Quant,
 		button2.Capture,
-	})
+	},
+}
+
+/*
+	UnpackPacket reads a single input report from the reader, see UnmarshalBinary.
+*/
+func (packet *InState) UnpackPacket(rdr io.Reader) error {
+	data := make([]byte, InReportSize)
+	_, err := io.ReadFull(rdr, data)
+	if err != nil {
+		return errors.WithMessage(err, "failed to read HID packet")
+	}
+	return packet.UnmarshalBinary(data)
+}
+
+/*
+	UnmarshalBinary decodes a 22 bytes input report, an ErrReportLength or ErrReportVersion is
+	returned if the report is malformed.
+*/
+func (packet *InState) UnmarshalBinary(data []byte) error {
+	if len(data) != InReportSize {
+		return &ErrReportLength{Report: "input", Expected: InReportSize, Actual: len(data)}
+	}
+
+	if data[0] != InReportVersion {
+		return &ErrReportVersion{Report: "input", Expected: InReportVersion, Actual: data[0]}
+	}
+	packet.Version = data[0]
+
+	if packet.PressedButtons == nil {
+		packet.PressedButtons = map[button2.Button]button2.PushState{}
+	}
+	for idx, buttons := range inputButtonBits {
+		packet.unpackbools(data[1+idx], buttons)
+	}
 
 	/*
 		Rotary Encoder
@@ -150,10 +172,7 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 		increments the value by 1 up to a maximum of 0xFF (255). Incrementing past 255 results in wrap around to 0 and
 		decrementing through 0 wraps to 255.
 	*/
-	err = binary.Read(rdr, binary.BigEndian, &packet.Dial)
-	if err != nil {
-		return errors.WithMessage(err, "failed to read HID packet")
-	}
+	packet.Dial = data[5]
 
 	/*
 		Analog Inputs
@@ -165,38 +184,53 @@ func (packet *InState) UnpackPacket(rdr io.Reader) error {
 
 		ie; a decimal value of 4000, usually represented as 0x0FA0 in hexadecimal will be sent as the byte stream  {0xA0, 0x0F}
 	*/
-	twobytes := uint16(0)
-	for idx, _ := range packet.Filters {
-		err = binary.Read(rdr, binary.LittleEndian, &twobytes)
-		if err != nil {
-			return errors.WithMessage(err, "failed to read HID packet")
-		}
-
-		packet.Filters[idx] = unpackuint16(twobytes)
+	offset := 6
+	for idx := range packet.Filters {
+		packet.Filters[idx] = unpackuint16(binary.LittleEndian.Uint16(data[offset:]))
+		offset += 2
 	}
-	for idx, _ := range packet.Volumes {
-		err = binary.Read(rdr, binary.LittleEndian, &twobytes)
-		if err != nil {
-			return errors.WithMessage(err, "failed to read HID packet")
-		}
-
-		packet.Volumes[idx] = unpackuint16(twobytes)
+	for idx := range packet.Volumes {
+		packet.Volumes[idx] = unpackuint16(binary.LittleEndian.Uint16(data[offset:]))
+		offset += 2
 	}
 
 	// log.Infof("Volumes: %v, Sliders: %v", packet.Volumes, packet.Filters)
 	return nil
 }
 
-// func packbools(bools []bool) (packed byte) {
-// 	for bit, bol := range bools {
-// 		val := 0
-// 		if bol {
-// 			val = 1
-// 		}
-// 		packed |= val << (7 - bit)
-// 	}
-// 	return packed
-// }
+/*
+	MarshalBinary encodes the state into a 22 bytes input report, as it would be sent by a F1.
+*/
+func (packet InState) MarshalBinary() ([]byte, error) {
+	data := make([]byte, InReportSize)
+	data[0] = InReportVersion
+
+	for idx, buttons := range inputButtonBits {
+		data[1+idx] = packet.packbools(buttons)
+	}
+
+	data[5] = packet.Dial
+
+	offset := 6
+	for _, val := range packet.Filters {
+		binary.LittleEndian.PutUint16(data[offset:], val&AnalogMax)
+		offset += 2
+	}
+	for _, val := range packet.Volumes {
+		binary.LittleEndian.PutUint16(data[offset:], val&AnalogMax)
+		offset += 2
+	}
+	return data, nil
+}
+
+func (i InState) packbools(buttons []button2.Button) (packed byte) {
+	for bit, btn := range buttons {
+		if i.PressedButtons[btn] == button2.Pushed {
+			packed |= 1 << (7 - bit)
+		}
+	}
+	return packed
+}
 
 func unpackuint16(data uint16) uint16 {
 	first := byte(data)
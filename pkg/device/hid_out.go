@@ -4,26 +4,26 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"math"
 
 	"github.com/pkg/errors"
 
-	"github.com/bearsh/hid"
-
 	button2 "github.com/draeron/gof1/pkg/f1/button"
 	"github.com/draeron/gopkgs/color"
 	seven_bits "github.com/draeron/gopkgs/color/7bits"
 )
 
 type OutState struct {
-	SevenSegment int8 // [-99,99] sign means dot is turned on
-	Functions    map[button2.Button]LEDIntensity
-	Pads         [16]color.Color
-	Mute         [4]LEDIntensity
+	Display   Display
+	Functions map[button2.Button]LEDIntensity
+	Pads      [16]color.Color
+	Mute      [4]MuteLEDs
 }
 
 type LEDIntensity uint8
 
+// MuteLEDs are the intensities of the two LEDs lighting a mute (stop) key
+type MuteLEDs [2]LEDIntensity
+
 func (l LEDIntensity) Value() uint8 {
 	if l > 127 {
 		return 127
@@ -44,6 +44,28 @@ func NewOutState() OutState {
 	return o
 }
 
+// Copy returns a deep copy of the state
+func (o OutState) Copy() OutState {
+	cpy := o
+	cpy.Functions = map[button2.Button]LEDIntensity{}
+	for k, v := range o.Functions {
+		cpy.Functions[k] = v
+	}
+	return cpy
+}
+
+// order of the small function keys in the output report
+var outputFunctions = []button2.Button{
+	button2.Browse,
+	button2.Size,
+	button2.Type,
+	button2.Reverse,
+	button2.Shift,
+	button2.Capture,
+	button2.Quant,
+	button2.Sync,
+}
+
 // Segments Order: G, C, B, A, F, E, D
 type Segments [7]byte
 
@@ -71,7 +93,27 @@ func init() {
 	}
 }
 
-func (o OutState) Write(device *hid.Device) error {
+// OutReportSize is the length in bytes of an output report
+const OutReportSize = 81
+
+// OutReportID is the first byte of every output report
+const OutReportID = 0x80
+
+func (o OutState) Write(transport Transport) error {
+	packet, err := o.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	wrote, err := transport.Write(packet)
+	log.Debugf("wrote %d bytes to HID devices", wrote)
+	return errors.WithMessage(err, "failed to write HID packet")
+}
+
+/*
+	MarshalBinary encodes the state into a 81 bytes output report.
+*/
+func (o OutState) MarshalBinary() ([]byte, error) {
 	var err error
 
 	writer := bytes.NewBuffer([]byte{})
@@ -95,9 +137,9 @@ func (o OutState) Write(device *hid.Device) error {
 
 	*/
 	// 		The first byte is always 80.
-	err = binary.Write(writer, binary.LittleEndian, byte(0x80))
+	err = binary.Write(writer, binary.LittleEndian, byte(OutReportID))
 	if err != nil {
-		return errors.WithMessage(err, "failed to write HID packet")
+		return nil, errors.WithMessage(err, "failed to write HID packet")
 	}
 
 	/*
@@ -118,39 +160,15 @@ func (o OutState) Write(device *hid.Device) error {
 		Byte 7: Segment E (left-bott
 		Byte 8: Segment D (bot)
 	*/
-	dot := byte(0)
-	if o.SevenSegment < 0 {
-		dot = On
-	}
-	err = binary.Write(writer, binary.LittleEndian, dot)
-	if err != nil {
-		return errors.WithMessage(err, "failed to write HID packet")
-	}
-
-	absolute := int8(math.Abs(float64(o.SevenSegment)))
-	if absolute > 99 {
-		absolute = 99
-	}
-
-	val := absolute % 10
-	if segments, ok := NumberSegmentMapping[val]; ok {
-		err = binary.Write(writer, binary.LittleEndian, segments)
+	for _, digit := range []Digit{o.Display.Right, o.Display.Left} {
+		err = binary.Write(writer, binary.LittleEndian, digit.dotValue())
 		if err != nil {
-			return errors.WithMessage(err, "failed to write HID packet")
+			return nil, errors.WithMessage(err, "failed to write HID packet")
 		}
-	} else {
-		panic(fmt.Sprintf("missing segment map for %v", val))
-	}
-
-	val = absolute / 10
-	err = binary.Write(writer, binary.LittleEndian, dot)
-	if segments, ok := NumberSegmentMapping[val]; ok {
-		err = binary.Write(writer, binary.LittleEndian, segments)
+		err = binary.Write(writer, binary.LittleEndian, digit.Segments)
 		if err != nil {
-			return errors.WithMessage(err, "failed to write HID packet")
+			return nil, errors.WithMessage(err, "failed to write HID packet")
 		}
-	} else {
-		panic(fmt.Sprintf("missing segment map for %v", val))
 	}
 
 	/*
@@ -167,18 +185,11 @@ func (o OutState) Write(device *hid.Device) error {
 		Byte 7     Quant
 		Byte 8     Sync
 	*/
-	err = binary.Write(writer, binary.LittleEndian, []byte{
-		o.Functions[button2.Browse].Value(),
-		o.Functions[button2.Size].Value(),
-		o.Functions[button2.Type].Value(),
-		o.Functions[button2.Reverse].Value(),
-		o.Functions[button2.Shift].Value(),
-		o.Functions[button2.Capture].Value(),
-		o.Functions[button2.Quant].Value(),
-		o.Functions[button2.Sync].Value(),
-	})
-	if err != nil {
-		return errors.WithMessage(err, "failed to write HID packet")
+	for _, btn := range outputFunctions {
+		err = binary.Write(writer, binary.LittleEndian, o.Functions[btn].Value())
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to write HID packet")
+		}
 	}
 
 	/*
@@ -208,7 +219,7 @@ func (o OutState) Write(device *hid.Device) error {
 			rgb.G,
 		})
 		if err != nil {
-			return errors.WithMessage(err, "failed to write HID packet")
+			return nil, errors.WithMessage(err, "failed to write HID packet")
 		}
 	}
 
@@ -230,20 +241,56 @@ func (o OutState) Write(device *hid.Device) error {
 	for idx := len(button2.Mutes()) - 1; idx >= 0; idx-- {
 		mute := o.Mute[idx]
 		err = binary.Write(writer, binary.LittleEndian, []byte{
-			mute.Value(),
-			mute.Value(),
+			mute[0].Value(),
+			mute[1].Value(),
 		})
 		if err != nil {
-			return errors.WithMessage(err, "failed to write HID packet")
+			return nil, errors.WithMessage(err, "failed to write HID packet")
 		}
 	}
 
 	packet := writer.Bytes()
-	if len(packet) != 81 {
-		panic(fmt.Sprintf("wrong computed packet size, current: %v, spec: 81", len(packet)))
+	if len(packet) != OutReportSize {
+		return nil, &ErrReportLength{Report: "output", Expected: OutReportSize, Actual: len(packet)}
 	}
+	return packet, nil
+}
 
-	wrote, err := device.Write(packet)
-	log.Debugf("wrote %d bytes to HID devices", wrote)
-	return errors.WithMessage(err, "failed to write HID packet")
+/*
+	UnmarshalBinary decodes a 81 bytes output report. An ErrReportLength or ErrReportVersion is returned
+	if the report is malformed.
+*/
+func (o *OutState) UnmarshalBinary(data []byte) error {
+	if len(data) != OutReportSize {
+		return &ErrReportLength{Report: "output", Expected: OutReportSize, Actual: len(data)}
+	}
+	if data[0] != OutReportID {
+		return &ErrReportVersion{Report: "output", Expected: OutReportID, Actual: data[0]}
+	}
+
+	if o.Functions == nil {
+		o.Functions = map[button2.Button]LEDIntensity{}
+	}
+	for idx, btn := range outputFunctions {
+		o.Functions[btn] = LEDIntensity(data[17+idx])
+	}
+
+	for idx := range o.Pads {
+		offset := 25 + idx*3
+		o.Pads[idx] = seven_bits.SevenColor{
+			B: data[offset],
+			R: data[offset+1],
+			G: data[offset+2],
+		}
+	}
+
+	for idx := range o.Mute {
+		// stop keys are sent from the last column to the first
+		offset := 73 + (len(o.Mute)-1-idx)*2
+		o.Mute[idx] = MuteLEDs{LEDIntensity(data[offset]), LEDIntensity(data[offset+1])}
+	}
+
+	o.Display.Right = unpackDigit(data[1:9])
+	o.Display.Left = unpackDigit(data[9:17])
+	return nil
 }
@@ -0,0 +1,161 @@
+package device
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	button2 "github.com/draeron/gof1/pkg/f1/button"
+	"github.com/draeron/gopkgs/color"
+)
+
+func TestInStateRoundTrip(t *testing.T) {
+	pressed := NewInState()
+	pressed.PressedButtons[button2.PadA1] = button2.Pushed
+	pressed.PressedButtons[button2.PadD4] = button2.Pushed
+	pressed.PressedButtons[button2.Shift] = button2.Pushed
+	pressed.PressedButtons[button2.Dial] = button2.Pushed
+	pressed.PressedButtons[button2.Capture] = button2.Pushed
+	pressed.Dial = 0xFF
+	pressed.Filters = [4]uint16{0, 1, 0x800, AnalogMax}
+	pressed.Volumes = [4]uint16{AnalogMax, 0x123, 0xABC, 0}
+
+	all := NewInState()
+	for _, buttons := range inputButtonBits {
+		for _, btn := range buttons {
+			all.PressedButtons[btn] = button2.Pushed
+		}
+	}
+
+	tests := []struct {
+		name  string
+		state *InState
+	}{
+		{"released", NewInState()},
+		{"pressed", pressed},
+		{"all pressed", all},
+	}
+
+	for _, test := range tests {
+		test.state.Version = InReportVersion
+
+		data, err := test.state.MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: marshal: %v", test.name, err)
+			continue
+		}
+		if len(data) != InReportSize {
+			t.Errorf("%s: report is %d bytes", test.name, len(data))
+			continue
+		}
+
+		decoded := NewInState()
+		err = decoded.UnmarshalBinary(data)
+		if err != nil {
+			t.Errorf("%s: unmarshal: %v", test.name, err)
+			continue
+		}
+		// only the buttons of the report are compared, the initial state also has buttons without a bit
+		if decoded.Version != test.state.Version || decoded.Dial != test.state.Dial ||
+			decoded.Filters != test.state.Filters || decoded.Volumes != test.state.Volumes {
+			t.Errorf("%s: got %+v, expected %+v", test.name, decoded, test.state)
+		}
+		for _, buttons := range inputButtonBits {
+			for _, btn := range buttons {
+				if decoded.PressedButtons[btn] != test.state.PressedButtons[btn] {
+					t.Errorf("%s: %v is %v", test.name, btn, decoded.PressedButtons[btn])
+				}
+			}
+		}
+	}
+}
+
+func TestOutStateRoundTrip(t *testing.T) {
+	lit := NewOutState()
+	lit.Display = NumberDisplay(-42)
+	lit.Display.Left.Dot = true
+	lit.Functions[button2.Browse] = 127
+	lit.Functions[button2.Sync] = 10
+	lit.Pads[0] = color.Red
+	lit.Pads[15] = color.White
+	lit.Mute[0] = MuteLEDs{127, 0}
+	lit.Mute[3] = MuteLEDs{1, 2}
+
+	tests := []struct {
+		name  string
+		state OutState
+	}{
+		{"off", NewOutState()},
+		{"lit", lit},
+	}
+
+	for _, test := range tests {
+		data, err := test.state.MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: marshal: %v", test.name, err)
+			continue
+		}
+
+		// pads are decoded as 7 bits colors, the encoded reports are compared instead of the states
+		decoded := NewOutState()
+		err = decoded.UnmarshalBinary(data)
+		if err != nil {
+			t.Errorf("%s: unmarshal: %v", test.name, err)
+			continue
+		}
+		again, err := decoded.MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: marshal decoded: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(data, again) {
+			t.Errorf("%s: got % x, expected % x", test.name, again, data)
+		}
+		if decoded.Display != test.state.Display || decoded.Mute != test.state.Mute {
+			t.Errorf("%s: got %+v, expected %+v", test.name, decoded, test.state)
+		}
+	}
+}
+
+func TestUnmarshalMalformed(t *testing.T) {
+	input, _ := NewInState().MarshalBinary()
+	output, _ := NewOutState().MarshalBinary()
+
+	badInput := append([]byte{}, input...)
+	badInput[0] = 0x02
+	badOutput := append([]byte{}, output...)
+	badOutput[0] = 0x01
+
+	tests := []struct {
+		name    string
+		decoder interface{ UnmarshalBinary([]byte) error }
+		data    []byte
+		length  bool
+		version bool
+	}{
+		{"input empty", NewInState(), nil, true, false},
+		{"input short", NewInState(), input[:InReportSize-1], true, false},
+		{"input long", NewInState(), append(append([]byte{}, input...), 0), true, false},
+		{"input version", NewInState(), badInput, false, true},
+		{"output empty", &OutState{}, nil, true, false},
+		{"output short", &OutState{}, output[:OutReportSize-1], true, false},
+		{"output long", &OutState{}, append(append([]byte{}, output...), 0), true, false},
+		{"output version", &OutState{}, badOutput, false, true},
+	}
+
+	for _, test := range tests {
+		err := test.decoder.UnmarshalBinary(test.data)
+
+		var length *ErrReportLength
+		var version *ErrReportVersion
+		switch {
+		case err == nil:
+			t.Errorf("%s: no error", test.name)
+		case errors.As(err, &length) != test.length:
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		case errors.As(err, &version) != test.version:
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+	}
+}
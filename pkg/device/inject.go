@@ -0,0 +1,88 @@
+package device
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+	"github.com/draeron/gof1/pkg/f1/event"
+)
+
+/*
+	Inject sends an event to the subscribers as if it came from the device, the event is marked as
+	Injected. The input state of the device isn't changed.
+
+	For faders and knobs, the value fields are derived from Raw, or from Normalized if Raw is 0, an
+	injected reading is neither calibrated nor filtered so Reading equals Raw.
+	For the dial, a Delta of 0 is one step in the direction of the event and the accumulated position
+	of the dial is moved by the delta.
+*/
+func (d *Device) Inject(evt event.Event) error {
+	if d.ctx.Err() != nil {
+		return errors.New("device is closed")
+	}
+
+	switch evt.Type {
+	case event.Pressed, event.Released:
+		if !evt.Btn.IsPad() && !evt.Btn.IsMute() && !evt.Btn.IsFunctions() && evt.Btn != button.Dial {
+			return errors.Errorf("button %v cannot be pressed", evt.Btn)
+		}
+		evt.Value = 0
+		if evt.Type == event.Pressed {
+			evt.Value = 1
+		}
+
+	case event.Changed:
+		if !evt.Btn.IsFader() && !evt.Btn.IsKnob() {
+			return errors.Errorf("button %v is not an analog control", evt.Btn)
+		}
+		if evt.Normalized < 0 || evt.Normalized > 1 || evt.Raw > AnalogMax {
+			return errors.Errorf("value of %v is out of range", evt)
+		}
+		raw := evt.Raw
+		if raw == 0 {
+			raw = uint16(math.Round(evt.Normalized * AnalogMax))
+		}
+		evt = withAnalog(evt, raw)
+
+	case event.Increment, event.Decrement:
+		if evt.Btn != button.Dial {
+			return errors.Errorf("button %v is not the dial", evt.Btn)
+		}
+		if evt.Delta == 0 {
+			evt.Delta = 1
+		}
+		if (evt.Type == event.Increment) != (evt.Delta > 0) {
+			evt.Delta = -evt.Delta
+		}
+
+		d.mutex.Lock()
+		d.dial.position += int64(evt.Delta)
+		evt.Position = d.dial.position
+		d.mutex.Unlock()
+
+	default:
+		return errors.Errorf("events of type %v cannot be injected", evt.Type)
+	}
+
+	evt.Source = event.Injected
+	evt.Group = 0
+	if evt.Time.IsZero() {
+		evt.Time = time.Now()
+	}
+
+	d.sendToSubscribers(evt)
+	return nil
+}
+
+// withAnalog replaces the value fields of an analog event by the ones of the reading
+func withAnalog(evt event.Event, raw uint16) event.Event {
+	analog := analogEvent(evt.Btn, raw)
+	evt.Value = analog.Value
+	evt.Raw = raw
+	evt.Reading = analog.Reading
+	evt.Normalized = analog.Normalized
+	return evt
+}
@@ -1,15 +1,22 @@
 package device
 
 import (
+	"time"
+
+	"github.com/pkg/errors"
+
 	event2 "github.com/draeron/gof1/pkg/f1/event"
 )
 
 type EventCallBack func(event2.Event)
 
 func (d *Device) AddCallback(filter event2.Filter, cb EventCallBack) {
+	input := make(chan event2.Event, 10)
+	d.subscribe(input, SubscriptionOptions{}, true)
+
+	d.wg.Add(1)
 	go func() {
-		input := make(chan event2.Event, 10)
-		d.Subscribe(input)
+		defer d.wg.Done()
 		for evt := range input {
 			if filter(evt) {
 				cb(evt)
@@ -18,14 +25,58 @@ func (d *Device) AddCallback(filter event2.Filter, cb EventCallBack) {
 	}()
 }
 
+/*
+	AddCallbackExpr is like AddCallback with the filter given as text, see event.Parse for the syntax.
+*/
+func (d *Device) AddCallbackExpr(expr string, cb EventCallBack) error {
+	filter, err := event2.Parse(expr)
+	if err != nil {
+		return errors.WithMessagef(err, "invalid filter '%s'", expr)
+	}
+	d.AddCallback(filter.Match, cb)
+	return nil
+}
+
+// emit sends events happening at the same time as one group, no other event is sent in between
+func (d *Device) emit(events []event2.Event, now time.Time) {
+	d.publishing.Lock()
+	defer d.publishing.Unlock()
+
+	group := d.groups.Inc()
+	for _, evt := range events {
+		evt.Time = now
+		evt.Group = group
+		d.publish(evt)
+	}
+}
+
+// sendToSubscribers sends a single event to every subscriber
 func (d *Device) sendToSubscribers(evt event2.Event) {
+	d.publishing.Lock()
+	defer d.publishing.Unlock()
+
+	d.publish(evt)
+}
+
+/*
+	publish stamps the event with the next sequence number, and the current time and a group of its own
+	if it has none, then sends it to every subscriber. Caller must hold the publishing lock so subscribers
+	receive events in sequence order whatever goroutine sends them.
+*/
+func (d *Device) publish(evt event2.Event) {
+	if evt.Time.IsZero() {
+		evt.Time = time.Now()
+	}
+	if evt.Group == 0 {
+		evt.Group = d.groups.Inc()
+	}
+	evt.Seq = d.sequence.Inc()
+
 	d.mutex.RLock()
-	defer d.mutex.RUnlock()
-	for _, channel := range d.subscribers {
-		select {
-		case channel <- evt:
-		default:
-			// full or closed channel
-		}
+	subscribers := append([]*Subscription{}, d.subscribers...)
+	d.mutex.RUnlock()
+
+	for _, sub := range subscribers {
+		sub.publish(evt)
 	}
 }
@@ -0,0 +1,150 @@
+package device
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/f1/event"
+)
+
+// Opener creates a new transport to a F1, it is used to reconnect a supervised device.
+type Opener func() (Transport, error)
+
+// DefaultReconnectInterval is the enumeration polling interval used while a supervised device is disconnected
+const DefaultReconnectInterval = time.Second
+
+/*
+	OpenWith creates a Device using a transport created by the opener, the opener is kept to reconnect
+	the device when supervised. The device has no serial so no calibration profile is loaded, a unit
+	found by Enumerate is opened with Descriptor.Open or Descriptor.OpenWrapped instead.
+*/
+func OpenWith(opener Opener, options ...OpenOption) (*Device, error) {
+	return OpenWithContext(context.Background(), opener, options...)
+}
+
+/*
+	OpenWithContext is like OpenWith, the device is shut down when the context is cancelled.
+*/
+func OpenWithContext(ctx context.Context, opener Opener, options ...OpenOption) (*Device, error) {
+	if opener == nil {
+		return nil, errors.New("opener cannot be nil")
+	}
+	transport, err := opener()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open transport")
+	}
+	return open(ctx, transport, Descriptor{Product: F1ProductName}, opener, options)
+}
+
+/*
+	Supervised is an open option supervising the device from the start, so a disconnection happening
+	before Supervise could be called is handled too. See Supervise.
+*/
+func Supervised(interval time.Duration) OpenOption {
+	return func(d *Device) error {
+		return d.Supervise(interval)
+	}
+}
+
+/*
+	Supervise enables the reconnection of the device when its transport fails, like when the F1 is unplugged.
+
+	While disconnected, the opener is polled at the given interval until the unit comes back. The last output
+	state is then sent again so the pads, LEDs and display are restored. Subscribers are notified with
+	event.Disconnected and event.Connected. An interval of 0 disables supervision.
+
+	An unsupervised device is shut down when its transport fails, after sending event.Disconnected.
+*/
+func (d *Device) Supervise(interval time.Duration) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if interval > 0 && d.opener == nil {
+		return errors.New("device cannot be reopened, it was created from a transport")
+	}
+	d.reconnectInterval = interval
+	return nil
+}
+
+// Connected returns false while a supervised device waits for its F1 to come back.
+func (d *Device) Connected() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.connected
+}
+
+/*
+	reconnect handles the loss of the transport, it returns the new transport or nil if the
+	device was closed while waiting. A device which isn't supervised is shut down.
+*/
+func (d *Device) reconnect(lost Transport, cause error) Transport {
+	if d.ctx.Err() != nil {
+		return nil
+	}
+
+	d.mutex.Lock()
+	interval := d.reconnectInterval
+	d.connected = false
+	// nothing can be held anymore
+	released := d.state.in.releaseAll()
+	d.mutex.Unlock()
+
+	d.emit(released, time.Now())
+
+	if interval <= 0 {
+		log.Errorf("failed to read buffer from HID device: %v", cause)
+		d.sendToSubscribers(event.Event{Type: event.Disconnected})
+		// the shutdown closes the lost transport and ends the subscriptions
+		d.cancel()
+		return nil
+	}
+
+	log.Warnf("lost connection to HID device: %v", cause)
+	lost.Close()
+	d.sendToSubscribers(event.Event{Type: event.Disconnected})
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+
+		d.mutex.RLock()
+		interval = d.reconnectInterval
+		d.mutex.RUnlock()
+		if interval <= 0 {
+			return nil
+		}
+
+		transport, err := d.opener()
+		if err != nil {
+			log.Debugf("device still unavailable: %v", err)
+			continue
+		}
+
+		// restore what was displayed before the disconnection
+		d.mutex.Lock()
+		if d.ctx.Err() != nil {
+			d.mutex.Unlock()
+			transport.Close()
+			return nil
+		}
+		err = d.state.out.Write(transport)
+		if err != nil {
+			d.mutex.Unlock()
+			log.Warnf("failed to restore HID state: %v", err)
+			transport.Close()
+			continue
+		}
+		d.transport = transport
+		d.connected = true
+		d.mutex.Unlock()
+
+		log.Infof("reconnected device: %v", transport)
+		d.sendToSubscribers(event.Event{Type: event.Connected})
+		return transport
+	}
+}
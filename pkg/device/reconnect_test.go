@@ -0,0 +1,90 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/draeron/gof1/pkg/device"
+	"github.com/draeron/gof1/pkg/f1/button"
+	"github.com/draeron/gof1/pkg/f1/event"
+	"github.com/draeron/gof1/pkg/virtual"
+)
+
+// expect reads events until one matches, failing after a while
+func expect(t *testing.T, channel <-chan event.Event, match func(event.Event) bool) event.Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case evt := <-channel:
+			if match(evt) {
+				return evt
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for an event")
+			return event.Event{}
+		}
+	}
+}
+
+func isType(tp event.Type) func(event.Event) bool {
+	return func(evt event.Event) bool {
+		return evt.Type == tp
+	}
+}
+
+func TestUnsupervisedLoss(t *testing.T) {
+	f1 := virtual.New()
+	dev, err := device.OpenWith(f1.Open)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(dev.Close)
+
+	events := make(chan event.Event, 100)
+	sub := dev.Subscribe(events)
+	if err := f1.Press(button.PadA1); err != nil {
+		t.Fatalf("press: %v", err)
+	}
+	expect(t, events, isType(event.Pressed))
+
+	f1.Unplug()
+
+	released := expect(t, events, isType(event.Released))
+	if released.Btn != button.PadA1 || released.Source != event.Lost {
+		t.Errorf("got %v, expected a lost release of the pad", released)
+	}
+	expect(t, events, isType(event.Disconnected))
+
+	for name, done := range map[string]<-chan struct{}{"device": dev.Done(), "subscription": sub.Done()} {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Errorf("%s is not done after the loss of an unsupervised device", name)
+		}
+	}
+}
+
+func TestSupervisedOption(t *testing.T) {
+	f1 := virtual.New()
+	dev, err := device.OpenWith(f1.Open, device.Supervised(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(dev.Close)
+
+	events := make(chan event.Event, 100)
+	dev.Subscribe(events)
+	f1.Unplug()
+	expect(t, events, isType(event.Disconnected))
+
+	f1.Plug()
+	expect(t, events, isType(event.Connected))
+	if !dev.Connected() {
+		t.Errorf("device is not connected after the F1 came back")
+	}
+
+	if _, err := device.OpenTransport(virtual.New(), device.Supervised(time.Second)); err == nil {
+		t.Errorf("a device created from a transport cannot be supervised")
+	}
+}
@@ -0,0 +1,55 @@
+package device
+
+import (
+	"github.com/pkg/errors"
+)
+
+/*
+	PushOutput saves the complete output state on a stack and returns the stack depth. Temporary
+	modifications can then be made and undone with PopOutput, snapshots can be nested.
+*/
+func (d *Device) PushOutput() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.outputStack = append(d.outputStack, d.state.out.Copy())
+	return len(d.outputStack)
+}
+
+/*
+	PopOutput restores the output state saved by the last PushOutput, it is sent as a single report.
+*/
+func (d *Device) PopOutput() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.outputStack) == 0 {
+		return errors.New("output stack is empty")
+	}
+
+	last := len(d.outputStack) - 1
+	d.state.out = d.outputStack[last]
+	d.outputStack = d.outputStack[:last]
+
+	return d.write()
+}
+
+// DropOutput discards the output state saved by the last PushOutput, keeping the current one.
+func (d *Device) DropOutput() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if len(d.outputStack) == 0 {
+		return errors.New("output stack is empty")
+	}
+	d.outputStack = d.outputStack[:len(d.outputStack)-1]
+	return nil
+}
+
+// SetOutput replaces the complete output state, it is sent as a single report.
+func (d *Device) SetOutput(out OutState) error {
+	return d.update(func(current *OutState) error {
+		*current = out.Copy()
+		return nil
+	})
+}
@@ -1,11 +1,16 @@
 package device
 
 import (
+	"sort"
+
 	button2 "github.com/draeron/gof1/pkg/f1/button"
 	event2 "github.com/draeron/gof1/pkg/f1/event"
 	"github.com/draeron/gopkgs/color"
 )
 
+/*
+	State is a snapshot of the device, both what is displayed by its LEDs and the state of its controls.
+*/
 type State struct {
 	in  InState
 	out OutState
@@ -21,79 +26,123 @@ type ButtonState struct {
 	button2.PushState
 }
 
-type RangeState uint16
+type MuteState struct {
+	MuteLEDs
+	button2.PushState
+}
 
-func (s *State) Copy() State {
-	// copy
-	st := *s
+type RangeState uint16
 
-	// create and cpy new map
-	st.out.Functions = map[button2.Button]LEDIntensity{}
-	for k, v := range st.out.Functions {
-		st.out.Functions[k] = v
+// Copy returns a deep copy of the state
+func (s State) Copy() State {
+	return State{
+		in:  s.in.Copy(),
+		out: s.out.Copy(),
 	}
-	return st
 }
 
-func (s *State) Pads() (states [16]PadState) {
+// Input returns a copy of the last input report received.
+func (s State) Input() InState {
+	return s.in.Copy()
+}
+
+// Output returns a copy of the output state currently displayed.
+func (s State) Output() OutState {
+	return s.out.Copy()
+}
+
+// Pads returns the state of the pads, indexed from PadA1.
+func (s State) Pads() (states [16]PadState) {
 	for _, it := range button2.Pads() {
-		states[it].PushState, _ = s.in.PressedButtons[it]
-		states[it].Color = s.out.Pads[it]
+		idx := it - button2.PadA1
+		states[idx].PushState = s.in.PressedButtons[it]
+		states[idx].Color = s.out.Pads[idx]
 	}
 	return
 }
 
-func (s *State) Functions() (states [16]ButtonState) {
+// Functions returns the state of the small function keys, indexed from Sync.
+func (s State) Functions() (states [8]ButtonState) {
 	for _, it := range button2.Functions() {
-		states[it].PushState, _ = s.in.PressedButtons[it]
-		states[it].LEDIntensity = s.out.Functions[it]
+		idx := it - button2.Sync
+		states[idx].PushState = s.in.PressedButtons[it]
+		states[idx].LEDIntensity = s.out.Functions[it]
+	}
+	return
+}
+
+// Mutes returns the state of the mute keys, indexed from Mute1.
+func (s State) Mutes() (states [4]MuteState) {
+	for _, it := range button2.Mutes() {
+		idx := it - button2.Mute1
+		states[idx].PushState = s.in.PressedButtons[it]
+		states[idx].MuteLEDs = s.out.Mute[idx]
 	}
 	return
 }
 
-func (s *State) Volumes() (states [4]RangeState) {
-	for it, _ := range button2.Volumes() {
+func (s State) Volumes() (states [4]RangeState) {
+	for it := range button2.Volumes() {
 		states[it] = RangeState(s.in.Volumes[it])
 	}
 	return
 }
 
-func (s *State) Knobs() (states [4]RangeState) {
-	for it, _ := range button2.Knobs() {
+func (s State) Knobs() (states [4]RangeState) {
+	for it := range button2.Knobs() {
 		states[it] = RangeState(s.in.Filters[it])
 	}
 	return
 }
 
+// Dial returns the raw wrapping counter of the encoder and if it's pushed.
+func (s State) Dial() (uint8, button2.PushState) {
+	return s.in.Dial, s.in.PressedButtons[button2.Dial]
+}
+
+// Display returns the content of the seven segment display.
+func (s State) Display() Display {
+	return s.out.Display
+}
+
+// orderedButtons lists the buttons in enum order, button.Values() is sorted the other way around
+var orderedButtons = func() []button2.Button {
+	btns := button2.Values()
+	sort.Slice(btns, func(i, j int) bool {
+		return btns[i] < btns[j]
+	})
+	return btns
+}()
+
+/*
+	eventFromDiff returns the events for the differences between the state and the current input,
+	ordered as the dial, the buttons in enum order, the faders then the knobs.
+*/
 func (s *State) eventFromDiff(current InState) []event2.Event {
 	evts := []event2.Event{}
 	previous := s.in
 
 	if current.Dial != previous.Dial {
-		evt := event2.Event{
-			Btn: button2.Dial,
-		}
+		// the counter wraps around, the signed difference gives the steps taken in either direction
+		steps := int8(current.Dial - previous.Dial)
 
-		pd := previous.Dial
-		cd := current.Dial
-		if (pd == 255 && cd == 0) || (pd == 0 && cd == 255) {
-			pd = current.Dial
-			cd = previous.Dial
+		evt := event2.Event{
+			Btn:   button2.Dial,
+			Type:  event2.Increment,
+			Value: int16(current.Dial),
+			Delta: int16(steps),
 		}
-
-		if cd > pd {
-			evt.Type = event2.Increment
-			evt.Value = int16(current.Dial)
-		} else {
+		if steps < 0 {
 			evt.Type = event2.Decrement
-			evt.Value = int16(current.Dial)
 		}
 
 		evts = append(evts, evt)
 	}
 
-	for key, state := range current.PressedButtons {
-		if current.PressedButtons[key] != previous.PressedButtons[key] {
+	// buttons are compared in enum order so events always come in the same order
+	for _, key := range orderedButtons {
+		state, ok := current.PressedButtons[key]
+		if ok && state != previous.PressedButtons[key] {
 			evt := event2.Event{
 				Btn: key,
 			}
@@ -109,27 +158,80 @@ func (s *State) eventFromDiff(current InState) []event2.Event {
 		}
 	}
 
-	const maxval = 4090
-
 	for idx, value := range current.Volumes {
 		if current.Volumes[idx] != previous.Volumes[idx] {
-			evts = append(evts, event2.Event{
-				Btn:   button2.Volume1 + button2.Button(idx),
-				Type:  event2.Changed,
-				Value: int16(float64(value) / maxval * 256),
-			})
+			evts = append(evts, analogEvent(button2.Volume1+button2.Button(idx), value))
 		}
 	}
 
 	for idx, value := range current.Filters {
 		if current.Filters[idx] != previous.Filters[idx] {
-			evts = append(evts, event2.Event{
-				Btn:   button2.Filter1 + button2.Button(idx),
-				Type:  event2.Changed,
-				Value: int16(float32(value) / maxval * 256),
-			})
+			evts = append(evts, analogEvent(button2.Filter1+button2.Button(idx), value))
+		}
+	}
+
+	return evts
+}
+
+/*
+	initialEvents returns the synthetic events describing the input state, the pressed buttons in enum order,
+	then the position of the faders and knobs.
+*/
+func (in InState) initialEvents() []event2.Event {
+	evts := []event2.Event{}
+
+	for _, key := range orderedButtons {
+		if in.PressedButtons[key] == button2.Pushed {
+			evts = append(evts, event2.Event{Btn: key, Type: event2.Pressed, Value: 1, Source: event2.Initial})
 		}
 	}
 
+	for idx, value := range in.Volumes {
+		evt := analogEvent(button2.Volume1+button2.Button(idx), value)
+		evt.Source = event2.Initial
+		evts = append(evts, evt)
+	}
+
+	for idx, value := range in.Filters {
+		evt := analogEvent(button2.Filter1+button2.Button(idx), value)
+		evt.Source = event2.Initial
+		evts = append(evts, evt)
+	}
+
 	return evts
 }
+
+/*
+	releaseAll marks every pressed button as released and returns the synthetic events for them.
+*/
+func (in *InState) releaseAll() []event2.Event {
+	evts := []event2.Event{}
+
+	for _, key := range orderedButtons {
+		if in.PressedButtons[key] == button2.Pushed {
+			in.PressedButtons[key] = button2.Released
+			evts = append(evts, event2.Event{Btn: key, Type: event2.Released, Value: 0, Source: event2.Lost})
+		}
+	}
+
+	return evts
+}
+
+// analogEvent creates the event of an analog control from its processed reading, Raw is left to the caller
+func analogEvent(btn button2.Button, value uint16) event2.Event {
+	return event2.Event{
+		Btn:        btn,
+		Type:       event2.Changed,
+		Value:      int16(uint32(value) * 255 / AnalogMax),
+		Reading:    value,
+		Normalized: float64(value) / AnalogMax,
+	}
+}
+
+// analog returns the reading of a fader or knob
+func (in InState) analog(btn button2.Button) uint16 {
+	if btn.IsKnob() {
+		return in.Filters[btn-button2.Filter1]
+	}
+	return in.Volumes[btn-button2.Volume1]
+}
@@ -0,0 +1,376 @@
+package device
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/draeron/gof1/pkg/f1/event"
+)
+
+//go:generate go-enum -f=$GOFILE --noprefix
+
+/*
+	Policy x ENUM(
+	DropNewest
+	DropOldest
+	Block
+	Coalesce
+)
+
+	Policy decides what happens to an event when the queue of a subscription is full.
+	DropNewest discards the incoming event, DropOldest discards the oldest queued event, Block waits for
+	the subscriber to catch up, stalling the device, up to the subscription timeout before dropping the
+	incoming event. Coalesce replaces a queued value of a fader or knob by its newer value and otherwise
+	discards the incoming event. Policies only apply to the queue, events already in the channel are kept.
+
+	Button presses and releases and connection events are never dropped whatever the policy, they take
+	the place of a queued analog event or are queued beyond the queue size.
+*/
+type Policy int
+
+// SubscriptionOptions configures how events are queued for a subscriber
+type SubscriptionOptions struct {
+	Policy Policy
+	// Number of events queued in front of the channel, the capacity of the channel if 0
+	QueueSize int
+	// For the Block policy, how long to wait for the subscriber, 0 waits until the device shuts down
+	Timeout time.Duration
+}
+
+/*
+	Subscription is a subscriber of the device events. Events are queued and forwarded to the subscriber
+	channel by a goroutine of the subscription so a slow subscriber never stalls the device, unless it
+	asks to with the Block policy.
+*/
+type Subscription struct {
+	device  *Device
+	channel chan<- event.Event
+	owned   bool // the channel was created by the device which closes it once done
+	options SubscriptionOptions
+
+	mutex  sync.Mutex
+	queue  []event.Event
+	closed bool
+
+	signal  chan struct{} // an event was queued
+	space   chan struct{} // an event was dequeued
+	stop    chan struct{} // unsubscribed, pending events are discarded
+	closing chan struct{} // the device shuts down, pending events are delivered if the channel has room
+	done    chan struct{} // no event will be sent anymore
+	once    sync.Once
+
+	dropped atomic.Uint64
+}
+
+/*
+	Subscribe registers a channel receiving every event of the device, events which don't fit are dropped.
+	The channel stays owned by the caller and is never closed by the device, Done of the returned handle
+	is closed once no event will be sent anymore, after unsubscribing or when the device shuts down.
+*/
+func (d *Device) Subscribe(channel chan<- event.Event) event.Subscription {
+	return d.SubscribeWith(channel, SubscriptionOptions{})
+}
+
+/*
+	SubscribeWith is like Subscribe, with control over how events are queued when the subscriber lags behind.
+*/
+func (d *Device) SubscribeWith(channel chan<- event.Event, options SubscriptionOptions) event.Subscription {
+	return d.subscribe(channel, options, false)
+}
+
+// subscribe registers the channel, an owned channel is closed once the subscription is done
+func (d *Device) subscribe(channel chan<- event.Event, options SubscriptionOptions, owned bool) *Subscription {
+	if options.QueueSize <= 0 {
+		options.QueueSize = cap(channel)
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = 1
+	}
+
+	sub := &Subscription{
+		device:  d,
+		channel: channel,
+		owned:   owned,
+		options: options,
+		signal:  make(chan struct{}, 1),
+		space:   make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.ctx.Err() != nil {
+		sub.closed = true
+		sub.close()
+		return sub
+	}
+
+	log.Infof("adding new event suscriber with policy %v", options.Policy)
+	d.subscribers = append(d.subscribers, sub)
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		sub.forward()
+	}()
+
+	return sub
+}
+
+/*
+	Unsubscribe stops the delivery of events and discards the pending ones, the subscriber channel can
+	be closed by its owner once it returns. It's safe to call it more than once and after the device shut down.
+*/
+func (s *Subscription) Unsubscribe() {
+	s.device.mutex.Lock()
+	for idx, sub := range s.device.subscribers {
+		if sub == s {
+			s.device.subscribers = append(s.device.subscribers[:idx], s.device.subscribers[idx+1:]...)
+			break
+		}
+	}
+	s.device.mutex.Unlock()
+
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+/*
+	Dropped returns the number of events which were never delivered to the subscriber, including the
+	analog values replaced by a newer one with the Coalesce policy.
+*/
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Done returns a channel closed once no event will be sent to the subscriber anymore.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// shutdown delivers the pending events which fit in the channel, then ends the subscription
+func (s *Subscription) shutdown() {
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+
+	close(s.closing)
+}
+
+/*
+	publish queues an event according to the policy, it only blocks with the Block policy
+*/
+func (s *Subscription) publish(evt event.Event) {
+	var timeout <-chan time.Time
+
+	for {
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			s.dropped.Inc()
+			return
+		}
+
+		if s.options.Policy == Coalesce && s.coalesce(evt) {
+			s.mutex.Unlock()
+			s.dropped.Inc()
+			return
+		}
+
+		if len(s.queue) < s.options.QueueSize {
+			s.queue = append(s.queue, evt)
+			s.mutex.Unlock()
+			s.wake(s.signal)
+			return
+		}
+
+		if s.options.Policy != Block && lossless(evt) {
+			s.force(evt)
+			s.mutex.Unlock()
+			s.wake(s.signal)
+			return
+		}
+
+		switch s.options.Policy {
+		case DropOldest:
+			if s.evict() {
+				s.queue = append(s.queue, evt)
+			} else {
+				s.dropped.Inc()
+			}
+			s.mutex.Unlock()
+			return
+
+		case Block:
+			s.mutex.Unlock()
+			if timeout == nil && s.options.Timeout > 0 {
+				timeout = time.After(s.options.Timeout)
+			}
+			select {
+			case <-s.space:
+				continue
+			case <-timeout:
+			case <-s.stop:
+			case <-s.device.ctx.Done():
+			}
+
+			s.mutex.Lock()
+			if !s.closed && lossless(evt) {
+				s.force(evt)
+				s.mutex.Unlock()
+				s.wake(s.signal)
+				return
+			}
+			s.mutex.Unlock()
+			s.dropped.Inc()
+			return
+
+		default:
+			s.mutex.Unlock()
+			s.dropped.Inc()
+			return
+		}
+	}
+}
+
+/*
+	lossless returns true for events which are never dropped, so a release is never missed and
+	buttons never appear stuck
+*/
+func lossless(evt event.Event) bool {
+	return evt.Type.IsDigital() || evt.Type.IsConnection()
+}
+
+/*
+	force queues a lossless event in a full queue, it takes the place of the oldest lossy event or grows
+	the queue if there is none, caller must hold the lock
+*/
+func (s *Subscription) force(evt event.Event) {
+	s.evict()
+	s.queue = append(s.queue, evt)
+}
+
+// evict drops the oldest lossy event of the queue, caller must hold the lock
+func (s *Subscription) evict() bool {
+	for idx := range s.queue {
+		if !lossless(s.queue[idx]) {
+			s.queue = append(s.queue[:idx], s.queue[idx+1:]...)
+			s.dropped.Inc()
+			return true
+		}
+	}
+	return false
+}
+
+// coalesce replaces the queued value of the same analog control, caller must hold the lock
+func (s *Subscription) coalesce(evt event.Event) bool {
+	if evt.Type != event.Changed || (!evt.Btn.IsFader() && !evt.Btn.IsKnob()) {
+		return false
+	}
+
+	for idx := len(s.queue) - 1; idx >= 0; idx-- {
+		if s.queue[idx].Type == event.Changed && s.queue[idx].Btn == evt.Btn {
+			s.queue[idx] = evt
+			return true
+		}
+	}
+	return false
+}
+
+// pop removes the oldest queued event
+func (s *Subscription) pop() (event.Event, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.queue) == 0 {
+		return event.Event{}, false
+	}
+	evt := s.queue[0]
+	s.queue = s.queue[1:]
+	s.wake(s.space)
+	return evt, true
+}
+
+func (s *Subscription) wake(signal chan struct{}) {
+	select {
+	case signal <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Subscription) forward() {
+	defer s.close()
+
+	for {
+		evt, ok := s.pop()
+		if !ok {
+			select {
+			case <-s.signal:
+				continue
+			case <-s.stop:
+				s.discard(0)
+				return
+			case <-s.closing:
+				s.flush()
+				return
+			}
+		}
+
+		select {
+		case s.channel <- evt:
+		case <-s.stop:
+			s.discard(1)
+			return
+		case <-s.closing:
+			s.deliver(evt)
+			s.flush()
+			return
+		}
+	}
+}
+
+// close signals the end of the subscription and closes the channel if the device owns it
+func (s *Subscription) close() {
+	if s.owned {
+		close(s.channel)
+	}
+	close(s.done)
+}
+
+// flush delivers the pending events which fit in the channel and drops the others
+func (s *Subscription) flush() {
+	for {
+		evt, ok := s.pop()
+		if !ok {
+			return
+		}
+		s.deliver(evt)
+	}
+}
+
+func (s *Subscription) deliver(evt event.Event) {
+	select {
+	case s.channel <- evt:
+	default:
+		s.dropped.Inc()
+	}
+}
+
+// discard drops the pending events and the ones being delivered
+func (s *Subscription) discard(inflight int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.dropped.Add(uint64(len(s.queue) + inflight))
+	s.queue = nil
+}
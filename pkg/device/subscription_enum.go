@@ -0,0 +1,51 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package device
+
+import (
+	"fmt"
+)
+
+const (
+	// DropNewest is a Policy of type DropNewest.
+	DropNewest Policy = iota
+	// DropOldest is a Policy of type DropOldest.
+	DropOldest
+	// Block is a Policy of type Block.
+	Block
+	// Coalesce is a Policy of type Coalesce.
+	Coalesce
+)
+
+const _PolicyName = "DropNewestDropOldestBlockCoalesce"
+
+var _PolicyMap = map[Policy]string{
+	0: _PolicyName[0:10],
+	1: _PolicyName[10:20],
+	2: _PolicyName[20:25],
+	3: _PolicyName[25:33],
+}
+
+// String implements the Stringer interface.
+func (x Policy) String() string {
+	if str, ok := _PolicyMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("Policy(%d)", x)
+}
+
+var _PolicyValue = map[string]Policy{
+	_PolicyName[0:10]:  0,
+	_PolicyName[10:20]: 1,
+	_PolicyName[20:25]: 2,
+	_PolicyName[25:33]: 3,
+}
+
+// ParsePolicy attempts to convert a string to a Policy
+func ParsePolicy(name string) (Policy, error) {
+	if x, ok := _PolicyValue[name]; ok {
+		return x, nil
+	}
+	return Policy(0), fmt.Errorf("%s is not a valid Policy", name)
+}
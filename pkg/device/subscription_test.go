@@ -0,0 +1,288 @@
+package device_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/draeron/gof1/pkg/device"
+	"github.com/draeron/gof1/pkg/f1/button"
+	"github.com/draeron/gof1/pkg/f1/event"
+	"github.com/draeron/gof1/pkg/virtual"
+)
+
+const (
+	moves     = 40  // fader moves sent to a stalled subscriber
+	moveStep  = 100 // raw difference between two moves, above the default hysteresis
+	idleDrain = 200 * time.Millisecond
+
+	channelSize = 4 // capacity of the channel of a stalled subscriber
+	queueSize   = 2
+)
+
+/*
+	harness opens a device on a virtual F1 and waits for the events of the first report, so the
+	subscriptions made by the tests only see the events they generate.
+*/
+type harness struct {
+	t      *testing.T
+	f1     *virtual.F1
+	device *device.Device
+	probe  chan event.Event
+}
+
+func newHarness(t *testing.T) *harness {
+	f1 := virtual.New()
+	dev, err := device.OpenTransport(f1)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(dev.Close)
+
+	initial := make(chan event.Event, 100)
+	sub := dev.Subscribe(initial)
+	if err := f1.TurnDial(0); err != nil {
+		t.Fatalf("first report: %v", err)
+	}
+	h := &harness{t: t, f1: f1, device: dev}
+	h.wait(initial, func(evt event.Event) bool {
+		return evt.Btn == button.Volume4
+	})
+	sub.Unsubscribe()
+	return h
+}
+
+/*
+	subscribe registers the subscription under test, then a probe with room for every event. Subscribers
+	are published to in order, once the probe received an event the subscription under test got it too.
+*/
+func (h *harness) subscribe(capacity int, options device.SubscriptionOptions) (chan event.Event, event.Subscription) {
+	channel := make(chan event.Event, capacity)
+	sub := h.device.SubscribeWith(channel, options)
+	h.t.Cleanup(sub.Unsubscribe)
+
+	h.probe = make(chan event.Event, 1000)
+	probe := h.device.Subscribe(h.probe)
+	h.t.Cleanup(probe.Unsubscribe)
+	return channel, sub
+}
+
+func (h *harness) wait(channel <-chan event.Event, last func(event.Event) bool) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case evt := <-channel:
+			if last(evt) {
+				return
+			}
+		case <-timeout:
+			h.t.Fatalf("timed out waiting for events")
+		}
+	}
+}
+
+// sync waits until the device published the event matched by last
+func (h *harness) sync(last func(event.Event) bool) {
+	h.wait(h.probe, last)
+}
+
+// moveFader moves the first fader by moveStep for every move, returning the raw values sent
+func (h *harness) moveFader(count int) []uint16 {
+	values := []uint16{}
+	for idx := 1; idx <= count; idx++ {
+		value := uint16(idx * moveStep)
+		if err := h.f1.MoveFader(button.Volume1, value); err != nil {
+			h.t.Fatalf("move fader: %v", err)
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+func isRaw(value uint16) func(event.Event) bool {
+	return func(evt event.Event) bool {
+		return evt.Btn == button.Volume1 && evt.Raw == value
+	}
+}
+
+// drain reads the events of the channel until none came for a while
+func drain(channel <-chan event.Event) (events []event.Event) {
+	for {
+		select {
+		case evt := <-channel:
+			events = append(events, evt)
+		case <-time.After(idleDrain):
+			return
+		}
+	}
+}
+
+func raws(events []event.Event) (values []uint16) {
+	for _, evt := range events {
+		if evt.Btn == button.Volume1 {
+			values = append(values, evt.Raw)
+		}
+	}
+	return
+}
+
+func ordered(values []uint16) bool {
+	for idx := 1; idx < len(values); idx++ {
+		if values[idx] <= values[idx-1] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+	fits returns true if the events received fill the queue, and at most the channel and the event being
+	forwarded, depending on how far the forwarding went before the queue was full
+*/
+func fits(received []uint16) bool {
+	return len(received) >= queueSize && len(received) <= channelSize+1+queueSize
+}
+
+func TestDropNewest(t *testing.T) {
+	h := newHarness(t)
+	channel, sub := h.subscribe(channelSize, device.SubscriptionOptions{Policy: device.DropNewest, QueueSize: queueSize})
+
+	sent := h.moveFader(moves)
+	h.sync(isRaw(sent[len(sent)-1]))
+	received := raws(drain(channel))
+
+	if !fits(received) {
+		t.Errorf("received %d events: %v", len(received), received)
+	}
+	for idx, value := range received {
+		if value != sent[idx] {
+			t.Errorf("received %v, expected the first events %v", received, sent[:len(received)])
+			break
+		}
+	}
+	if sub.Dropped() != uint64(len(sent)-len(received)) {
+		t.Errorf("dropped %d events, %d were received out of %d", sub.Dropped(), len(received), len(sent))
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	h := newHarness(t)
+	channel, sub := h.subscribe(channelSize, device.SubscriptionOptions{Policy: device.DropOldest, QueueSize: queueSize})
+
+	sent := h.moveFader(moves)
+	h.sync(isRaw(sent[len(sent)-1]))
+	received := raws(drain(channel))
+
+	if !fits(received) {
+		t.Errorf("received %d events: %v", len(received), received)
+	}
+	if !ordered(received) {
+		t.Errorf("events out of order: %v", received)
+	}
+	// the queue keeps the newest events
+	last := received[len(received)-2:]
+	if last[0] != sent[len(sent)-2] || last[1] != sent[len(sent)-1] {
+		t.Errorf("received %v, expected to end with %v", received, sent[len(sent)-2:])
+	}
+	if sub.Dropped() != uint64(len(sent)-len(received)) {
+		t.Errorf("dropped %d events, %d were received out of %d", sub.Dropped(), len(received), len(sent))
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	h := newHarness(t)
+	channel, sub := h.subscribe(channelSize, device.SubscriptionOptions{Policy: device.Coalesce, QueueSize: queueSize})
+
+	sent := h.moveFader(moves)
+	h.sync(isRaw(sent[len(sent)-1]))
+	received := raws(drain(channel))
+
+	if !ordered(received) {
+		t.Errorf("events out of order: %v", received)
+	}
+	if received[len(received)-1] != sent[len(sent)-1] {
+		t.Errorf("received %v, expected to end with the last value %v", received, sent[len(sent)-1])
+	}
+	// replaced values are counted as dropped
+	if sub.Dropped() == 0 || sub.Dropped() != uint64(len(sent)-len(received)) {
+		t.Errorf("dropped %d events, %d were received out of %d", sub.Dropped(), len(received), len(sent))
+	}
+}
+
+func TestBlock(t *testing.T) {
+	h := newHarness(t)
+	channel, sub := h.subscribe(channelSize, device.SubscriptionOptions{Policy: device.Block, QueueSize: queueSize})
+
+	// the device stalls until the subscriber reads, the reports wait in the virtual F1
+	sent := h.moveFader(moves)
+	received := raws(drain(channel))
+
+	if len(received) != len(sent) {
+		t.Errorf("received %d events out of %d: %v", len(received), len(sent), received)
+	}
+	if !ordered(received) {
+		t.Errorf("events out of order: %v", received)
+	}
+	if sub.Dropped() != 0 {
+		t.Errorf("dropped %d events", sub.Dropped())
+	}
+}
+
+func TestBlockTimeout(t *testing.T) {
+	h := newHarness(t)
+	channel, sub := h.subscribe(channelSize, device.SubscriptionOptions{
+		Policy:    device.Block,
+		QueueSize: queueSize,
+		Timeout:   5 * time.Millisecond,
+	})
+
+	sent := h.moveFader(moves)
+	h.sync(isRaw(sent[len(sent)-1]))
+	received := raws(drain(channel))
+
+	if !fits(received) {
+		t.Errorf("received %d events: %v", len(received), received)
+	}
+	if sub.Dropped() != uint64(len(sent)-len(received)) {
+		t.Errorf("dropped %d events, %d were received out of %d", sub.Dropped(), len(received), len(sent))
+	}
+}
+
+// TestLossless checks buttons are never dropped whatever the policy, so a pad cannot appear stuck
+func TestLossless(t *testing.T) {
+	for _, policy := range []device.Policy{device.DropNewest, device.DropOldest, device.Block, device.Coalesce} {
+		t.Run(policy.String(), func(t *testing.T) {
+			h := newHarness(t)
+			channel, sub := h.subscribe(1, device.SubscriptionOptions{
+				Policy:    policy,
+				QueueSize: 1,
+				Timeout:   time.Millisecond,
+			})
+
+			h.moveFader(moves)
+			for _, err := range []error{h.f1.Press(button.PadA1), h.f1.Release(button.PadA1)} {
+				if err != nil {
+					t.Fatalf("pad: %v", err)
+				}
+			}
+			h.moveFader(moves / 2)
+
+			h.sync(func(evt event.Event) bool {
+				return evt.Btn == button.PadA1 && evt.Type == event.Released
+			})
+
+			pad := []event.Type{}
+			for _, evt := range drain(channel) {
+				if evt.Btn == button.PadA1 {
+					pad = append(pad, evt.Type)
+				}
+			}
+
+			if len(pad) != 2 || pad[0] != event.Pressed || pad[1] != event.Released {
+				t.Errorf("pad events %v, expected a press and a release", pad)
+			}
+			if sub.Dropped() == 0 {
+				t.Errorf("no fader event was dropped, the queue was never full")
+			}
+		})
+	}
+}
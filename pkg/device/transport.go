@@ -0,0 +1,74 @@
+package device
+
+import (
+	"sync"
+
+	"github.com/bearsh/hid"
+	"github.com/pkg/errors"
+)
+
+/*
+	Transport is the link used by a Device to exchange HID reports with a F1.
+
+	Read must fill the buffer with a single input report and return its length, a zero length
+	with a nil error means no report was available yet. Write sends a single output report.
+
+	Close is called by the Device while its reader goroutine may be blocked in Read. It must make a
+	blocked Read return, and must not release anything a Read in progress still uses, either by waiting
+	for it to return or by unblocking it first. Read and Write must return an error once closed.
+*/
+type Transport interface {
+	Read(report []byte) (int, error)
+	Write(report []byte) (int, error)
+	Close() error
+}
+
+// how long a HID read waits for a report before giving back the hand to the reader loop
+const hidReadTimeout = 100 // ms
+
+var errTransportClosed = errors.New("HID transport is closed")
+
+/*
+	hidTransport is the default Transport backed by bearsh/hid. The HID handle is freed by Close, which waits
+	for reads and writes in progress so they never use a freed handle, a read returns within hidReadTimeout.
+*/
+type hidTransport struct {
+	device *hid.Device
+	mutex  sync.RWMutex
+	closed bool
+}
+
+func (h *hidTransport) Read(report []byte) (int, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if h.closed {
+		return 0, errTransportClosed
+	}
+	return h.device.ReadTimeout(report, hidReadTimeout)
+}
+
+func (h *hidTransport) Write(report []byte) (int, error) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	if h.closed {
+		return 0, errTransportClosed
+	}
+	return h.device.Write(report)
+}
+
+func (h *hidTransport) Close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	return h.device.Close()
+}
+
+func (h *hidTransport) String() string {
+	return h.device.Path
+}
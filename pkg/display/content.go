@@ -0,0 +1,65 @@
+package display
+
+import (
+	"time"
+
+	"github.com/draeron/gof1/pkg/device"
+)
+
+// content is a static display or the frames of a scrolling text
+type content struct {
+	frames []device.Display
+	start  time.Time
+}
+
+func newContent(display device.Display) *content {
+	return &content{
+		frames: []device.Display{display},
+		start:  time.Now(),
+	}
+}
+
+/*
+	newTextContent splits text longer than the display into frames, the text enters from the right
+	and leaves on the left.
+*/
+func newTextContent(text string) (*content, error) {
+	chars := []rune(text)
+	if len(chars) <= 2 {
+		display, err := device.TextDisplay(text)
+		if err != nil {
+			return nil, err
+		}
+		return newContent(display), nil
+	}
+
+	glyphs := []device.Segments{{}}
+	for _, char := range chars {
+		seg, err := device.Glyph(char)
+		if err != nil {
+			return nil, err
+		}
+		glyphs = append(glyphs, seg)
+	}
+	glyphs = append(glyphs, device.Segments{})
+
+	cnt := &content{start: time.Now()}
+	for idx := 0; idx < len(glyphs)-1; idx++ {
+		cnt.frames = append(cnt.frames, device.Display{
+			Left:  device.Digit{Segments: glyphs[idx]},
+			Right: device.Digit{Segments: glyphs[idx+1]},
+		})
+	}
+	return cnt, nil
+}
+
+// frame returns the frame shown at the given time and when the next one starts
+func (c *content) frame(now time.Time, speed time.Duration) (device.Display, time.Time) {
+	if len(c.frames) == 1 {
+		return c.frames[0], time.Time{}
+	}
+
+	step := int(now.Sub(c.start) / speed)
+	next := c.start.Add(time.Duration(step+1) * speed)
+	return c.frames[step%len(c.frames)], next
+}
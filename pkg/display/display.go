@@ -0,0 +1,224 @@
+package display
+
+import (
+	"sync"
+	"time"
+
+	"github.com/draeron/gof1/pkg/device"
+)
+
+// Sink is the seven segment output driven by a Controller, it is implemented by device.Device.
+type Sink interface {
+	SetDisplay(display device.Display) error
+}
+
+// Priority of an overlay, when several overlays are active the highest priority is shown
+type Priority int
+
+const (
+	Routine Priority = iota
+	Normal
+	Urgent
+)
+
+const DefaultScrollSpeed = time.Millisecond * 300
+
+/*
+	Controller manages the content of the seven segment display.
+
+	Text longer than the two digits is scrolled at the configured speed. Overlays temporarily replace the
+	content for a duration, after which the previous content comes back. When several overlays are active
+	the one with the highest priority is shown, the most recent one for equal priorities.
+*/
+type Controller struct {
+	sink  Sink
+	mutex sync.Mutex
+	speed time.Duration
+
+	base     *content
+	overlays []*overlay
+
+	wake chan struct{}
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+type overlay struct {
+	*content
+	priority Priority
+	expire   time.Time
+}
+
+func New(sink Sink) *Controller {
+	c := &Controller{
+		sink:  sink,
+		speed: DefaultScrollSpeed,
+		base:  &content{frames: []device.Display{{}}, start: time.Now()},
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run()
+	}()
+	return c
+}
+
+// SetScrollSpeed sets the time each step of a scrolling text stays on the display.
+func (c *Controller) SetScrollSpeed(step time.Duration) {
+	if step <= 0 {
+		step = DefaultScrollSpeed
+	}
+	c.mutex.Lock()
+	c.speed = step
+	c.mutex.Unlock()
+	c.notify()
+}
+
+// Show sets the persistent content, text longer than two characters is scrolled.
+func (c *Controller) Show(text string) error {
+	cnt, err := newTextContent(text)
+	if err != nil {
+		return err
+	}
+	c.setBase(cnt)
+	return nil
+}
+
+// ShowDisplay sets the persistent content to raw segments.
+func (c *Controller) ShowDisplay(display device.Display) {
+	c.setBase(newContent(display))
+}
+
+// ShowNumber sets the persistent content to a number, see device.NumberDisplay.
+func (c *Controller) ShowNumber(val int8) {
+	c.setBase(newContent(device.NumberDisplay(val)))
+}
+
+/*
+	Overlay shows the text for the given duration before returning to the previous content. A duration of
+	0 shows a scrolling text for a single pass.
+*/
+func (c *Controller) Overlay(text string, duration time.Duration, priority Priority) error {
+	cnt, err := newTextContent(text)
+	if err != nil {
+		return err
+	}
+
+	if duration <= 0 {
+		c.mutex.Lock()
+		duration = c.speed * time.Duration(len(cnt.frames))
+		c.mutex.Unlock()
+	}
+
+	c.addOverlay(cnt, duration, priority)
+	return nil
+}
+
+// OverlayDisplay shows raw segments for the given duration before returning to the previous content.
+func (c *Controller) OverlayDisplay(display device.Display, duration time.Duration, priority Priority) {
+	c.addOverlay(newContent(display), duration, priority)
+}
+
+// ClearOverlays removes every active overlay.
+func (c *Controller) ClearOverlays() {
+	c.mutex.Lock()
+	c.overlays = nil
+	c.mutex.Unlock()
+	c.notify()
+}
+
+// Close stops updating the display, it's safe to call it more than once and from several goroutines.
+func (c *Controller) Close() {
+	c.once.Do(func() {
+		close(c.done)
+	})
+	c.wg.Wait()
+}
+
+func (c *Controller) setBase(cnt *content) {
+	c.mutex.Lock()
+	c.base = cnt
+	c.mutex.Unlock()
+	c.notify()
+}
+
+func (c *Controller) addOverlay(cnt *content, duration time.Duration, priority Priority) {
+	c.mutex.Lock()
+	c.overlays = append(c.overlays, &overlay{
+		content:  cnt,
+		priority: priority,
+		expire:   time.Now().Add(duration),
+	})
+	c.mutex.Unlock()
+	c.notify()
+}
+
+func (c *Controller) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Controller) run() {
+	var last *device.Display
+
+	for {
+		frame, next := c.frame(time.Now())
+
+		if last == nil || *last != frame {
+			err := c.sink.SetDisplay(frame)
+			log.ErrorIf(err, "failed to update display")
+			last = &frame
+		}
+
+		var timer <-chan time.Time
+		if !next.IsZero() {
+			timer = time.After(time.Until(next))
+		}
+
+		select {
+		case <-c.done:
+			return
+		case <-c.wake:
+		case <-timer:
+		}
+	}
+}
+
+// frame returns what should be displayed now and when it will change, a zero time meaning never
+func (c *Controller) frame(now time.Time) (device.Display, time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// remove expired overlays and find the one shown
+	var active *overlay
+	overlays := c.overlays[:0]
+	for _, it := range c.overlays {
+		if !now.Before(it.expire) {
+			continue
+		}
+		overlays = append(overlays, it)
+		if active == nil || it.priority >= active.priority {
+			active = it
+		}
+	}
+	c.overlays = overlays
+
+	cnt := c.base
+	if active != nil {
+		cnt = active.content
+	}
+
+	frame, next := cnt.frame(now, c.speed)
+	for _, it := range c.overlays {
+		if next.IsZero() || it.expire.Before(next) {
+			next = it.expire
+		}
+	}
+	return frame, next
+}
@@ -0,0 +1,213 @@
+package display
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/draeron/gof1/pkg/device"
+)
+
+const (
+	step   = 100 * time.Millisecond
+	margin = 10 * time.Millisecond // covers the time elapsed between the test clock and the controller calls
+)
+
+// sink records every display sent by the controller
+type sink struct {
+	mutex    sync.Mutex
+	displays []device.Display
+}
+
+func (s *sink) SetDisplay(display device.Display) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.displays = append(s.displays, display)
+	return nil
+}
+
+func (s *sink) sent() []device.Display {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]device.Display{}, s.displays...)
+}
+
+// newIdle creates a controller which isn't running, frames are computed by calling frame with a chosen time
+func newIdle() *Controller {
+	return &Controller{
+		sink:  &sink{},
+		speed: step,
+		base:  newContent(device.Display{}),
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+func text(t *testing.T, text string) device.Display {
+	t.Helper()
+	display, err := device.TextDisplay(text)
+	if err != nil {
+		t.Fatalf("%q: %v", text, err)
+	}
+	return display
+}
+
+func TestScrollFrames(t *testing.T) {
+	c := newIdle()
+	if err := c.Show("bank"); err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	start := c.base.start
+
+	// the text enters from the right and leaves on the left, then starts over
+	expected := []string{" b", "ba", "an", "nk", "k ", " b"}
+	for idx, txt := range expected {
+		at := start.Add(time.Duration(idx)*step + step/2)
+		frame, next := c.frame(at)
+		if frame != text(t, txt) {
+			t.Errorf("frame %d is not %q", idx, txt)
+		}
+		if !next.Equal(start.Add(time.Duration(idx+1) * step)) {
+			t.Errorf("frame %d ends at %v, expected %v", idx, next.Sub(start), time.Duration(idx+1)*step)
+		}
+	}
+
+	if err := c.Show("Fx"); err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	frame, next := c.frame(time.Now())
+	if frame != text(t, "Fx") || !next.IsZero() {
+		t.Errorf("short text should be static")
+	}
+}
+
+func TestOverlayExpires(t *testing.T) {
+	c := newIdle()
+	c.ShowNumber(12)
+
+	now := time.Now()
+	if err := c.Overlay("Fx", time.Second, Normal); err != nil {
+		t.Fatalf("overlay: %v", err)
+	}
+
+	frame, next := c.frame(now)
+	if frame != text(t, "Fx") {
+		t.Errorf("overlay is not shown")
+	}
+	if next.Before(now.Add(time.Second)) || next.After(now.Add(time.Second+margin)) {
+		t.Errorf("display changes in %v, expected when the overlay expires", next.Sub(now))
+	}
+
+	frame, next = c.frame(now.Add(time.Second + margin))
+	if frame != device.NumberDisplay(12) || !next.IsZero() {
+		t.Errorf("expired overlay didn't return to the base content")
+	}
+	if len(c.overlays) != 0 {
+		t.Errorf("expired overlay was kept")
+	}
+}
+
+func TestOverlayPriority(t *testing.T) {
+	c := newIdle()
+	now := time.Now()
+
+	overlays := []struct {
+		text     string
+		duration time.Duration
+		priority Priority
+	}{
+		{"aa", 3 * time.Second, Routine},
+		{"bb", time.Second, Urgent},
+		{"cc", 2 * time.Second, Routine},
+		{"dd", 3 * time.Second, Normal},
+		{"EE", 2 * time.Second, Normal},
+	}
+	for _, it := range overlays {
+		if err := c.Overlay(it.text, it.duration, it.priority); err != nil {
+			t.Fatalf("overlay: %v", err)
+		}
+	}
+
+	tests := []struct {
+		at   time.Duration
+		text string
+	}{
+		{0, "bb"},               // urgent wins over more recent overlays
+		{time.Second, "EE"},     // most recent of the normal overlays
+		{2 * time.Second, "dd"}, // the other normal overlay is back
+		{3 * time.Second, ""},   // all expired
+	}
+	for _, test := range tests {
+		frame, _ := c.frame(now.Add(test.at + margin))
+		if frame != text(t, test.text) {
+			t.Errorf("at %v, %q should be shown", test.at, test.text)
+		}
+	}
+}
+
+func TestOverlaySinglePass(t *testing.T) {
+	c := newIdle()
+	c.ShowNumber(7)
+
+	now := time.Now()
+	if err := c.Overlay("bank", 0, Urgent); err != nil {
+		t.Fatalf("overlay: %v", err)
+	}
+
+	// the 5 frames of the text are shown once
+	frames := 5 * step
+	if expire := c.overlays[0].expire.Sub(now); expire < frames || expire > frames+margin {
+		t.Errorf("overlay lasts %v, expected %v", expire, frames)
+	}
+
+	frame, _ := c.frame(now.Add(frames - margin))
+	if frame != text(t, "k ") {
+		t.Errorf("last frame of the text is not shown")
+	}
+	frame, _ = c.frame(now.Add(frames + margin))
+	if frame != device.NumberDisplay(7) {
+		t.Errorf("base content is not back after a single pass")
+	}
+}
+
+func TestControllerSink(t *testing.T) {
+	out := &sink{}
+	c := New(out)
+	c.SetScrollSpeed(10 * time.Millisecond)
+	if err := c.Show("bank"); err != nil {
+		t.Fatalf("show: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(out.sent()) < 12 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Close()
+	c.Close()
+	sent := out.sent()
+
+	// after the blank base content, the frames are sent once each time they change, a late timer may skip one
+	frames := map[device.Display]bool{}
+	for _, txt := range []string{" b", "ba", "an", "nk", "k "} {
+		frames[text(t, txt)] = false
+	}
+	for idx, display := range sent {
+		if _, ok := frames[display]; ok {
+			frames[display] = true
+		} else if idx > 0 || display != (device.Display{}) {
+			t.Errorf("display %d is not a frame of the text", idx)
+		}
+		if idx > 0 && display == sent[idx-1] {
+			t.Errorf("display %d was sent twice in a row", idx)
+		}
+	}
+	for display, shown := range frames {
+		if !shown {
+			t.Errorf("frame %v was never sent", display)
+		}
+	}
+
+	if len(out.sent()) != len(sent) {
+		t.Errorf("display updated after being closed")
+	}
+}
@@ -0,0 +1,11 @@
+package display
+
+import (
+	"github.com/draeron/gopkgs/logger"
+)
+
+var log logger.Logger = logger.Dummy{}
+
+func SetLogger(newlogger logger.Logger) {
+	log = newlogger
+}
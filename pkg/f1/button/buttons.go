@@ -4,7 +4,7 @@ import (
 	"sort"
 )
 
-//go:generate go-enum -f=$GOFILE --noprefix
+//go:generate go-enum -f=$GOFILE --noprefix --marshal
 
 /*
 Button x ENUM(
@@ -185,3 +185,19 @@ func ParseButton(name string) (Button, error) {
 	}
 	return Button(0), fmt.Errorf("%s is not a valid Button", name)
 }
+
+// MarshalText implements the text marshaller method
+func (x Button) MarshalText() ([]byte, error) {
+	return []byte(x.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (x *Button) UnmarshalText(text []byte) error {
+	name := string(text)
+	tmp, err := ParseButton(name)
+	if err != nil {
+		return err
+	}
+	*x = tmp
+	return nil
+}
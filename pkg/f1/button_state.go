@@ -40,11 +40,20 @@ func (bm *ButtonStateMap) Get(btn button.Button) *ButtonState {
 }
 
 func (bm *ButtonStateMap) Press(btn button.Button) {
+	bm.PressAt(btn, time.Now())
+}
+
+// PressAt marks the button as pressed since the given time, the current time is used if zero.
+func (bm *ButtonStateMap) PressAt(btn button.Button, at time.Time) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
 	bm.mutex.Lock()
 	defer bm.mutex.Unlock()
 
 	if b, ok := bm.data[btn]; ok {
-		b.pressTime = time.Now()
+		b.pressTime = at
 	}
 }
 
@@ -2,6 +2,7 @@ package event
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/draeron/gof1/pkg/f1/button"
 )
@@ -9,13 +10,51 @@ import (
 type Event struct {
 	Type  Type
 	Btn   button.Button
-	Value int16
+	Value int16 // for analog controls, the legacy 8 bits value, for the dial, the raw wrapping counter
+
+	Time  time.Time // when the HID report the event comes from was read
+	Seq   uint64    // increases by one for every event emitted by a device, a gap means events were dropped
+	Group uint64    // events decoded from the same report, e.g. buttons pressed at the same time, share a group
+
+	Source Source // Hardware for events decoded from the device reports, otherwise the event is synthetic
+
+	Delta    int16 // only valid for dial turns, the signed number of steps taken, after acceleration
+	Position int64 // only valid for dial turns, the accumulated position of the dial
+
+	Raw        uint16  // only valid for analog controls, the 12 bits ADC reading as sent by the device
+	Reading    uint16  // only valid for analog controls, the 12 bits reading after calibration and noise suppression
+	Normalized float64 // only valid for analog controls, the processed reading scaled to [0,1]
+}
+
+// Subscription is the handle returned when subscribing to the events of a controller
+type Subscription interface {
+	// Unsubscribe stops the delivery of events to the subscriber
+	Unsubscribe()
+	// Dropped returns the number of events which were never delivered to the subscriber
+	Dropped() uint64
+	// Done returns a channel closed once no event will be sent to the subscriber anymore
+	Done() <-chan struct{}
+}
+
+// IsSynthetic returns true for events which weren't decoded from a report of the device
+func (e Event) IsSynthetic() bool {
+	return e.Source != Hardware
 }
 
 func (e Event) String() string {
+	if e.Type.IsConnection() {
+		return fmt.Sprintf("Event: %s", e.Type)
+	}
 	str := fmt.Sprintf("Event: %s - %s", e.Btn, e.Type)
-	if e.Type == Changed || e.Type == Increment || e.Type == Decrement {
+	if e.Type == Changed && (e.Btn.IsFader() || e.Btn.IsKnob()) {
+		str += fmt.Sprintf(" - %v (reading: %v, raw: %v, %.3f)", e.Value, e.Reading, e.Raw, e.Normalized)
+	} else if e.Type == Increment || e.Type == Decrement {
+		str += fmt.Sprintf(" - %+d (position: %v)", e.Delta, e.Position)
+	} else if e.Type == Changed {
 		str += fmt.Sprintf(" - %v", e.Value)
 	}
+	if e.IsSynthetic() {
+		str += fmt.Sprintf(" [%v]", e.Source)
+	}
 	return str
 }
@@ -9,6 +9,18 @@ package event
 	Changed
 	Increment
 	Decrement
+	Connected
+	Disconnected
 */
 // )
 type Type int
+
+// IsConnection returns true for events notifying the device connection status
+func (x Type) IsConnection() bool {
+	return x == Connected || x == Disconnected
+}
+
+// IsDigital returns true for button press and release events
+func (x Type) IsDigital() bool {
+	return x == Pressed || x == Released
+}
@@ -18,9 +18,13 @@ const (
 	Increment
 	// Decrement is a Type of type Decrement.
 	Decrement
+	// Connected is a Type of type Connected.
+	Connected
+	// Disconnected is a Type of type Disconnected.
+	Disconnected
 )
 
-const _TypeName = "PressedReleasedChangedIncrementDecrement"
+const _TypeName = "PressedReleasedChangedIncrementDecrementConnectedDisconnected"
 
 var _TypeMap = map[Type]string{
 	0: _TypeName[0:7],
@@ -28,6 +32,8 @@ var _TypeMap = map[Type]string{
 	2: _TypeName[15:22],
 	3: _TypeName[22:31],
 	4: _TypeName[31:40],
+	5: _TypeName[40:49],
+	6: _TypeName[49:61],
 }
 
 // String implements the Stringer interface.
@@ -44,6 +50,8 @@ var _TypeValue = map[string]Type{
 	_TypeName[15:22]: 2,
 	_TypeName[22:31]: 3,
 	_TypeName[31:40]: 4,
+	_TypeName[40:49]: 5,
+	_TypeName[49:61]: 6,
 }
 
 // ParseType attempts to convert a string to a Type
@@ -0,0 +1,268 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+)
+
+/*
+	Expr is a filter which can be described as text with String and the description parsed back with
+	Parse, unless it was created by Func. Its Match method can be used wherever a Filter is expected.
+*/
+type Expr interface {
+	Match(evt Event) bool
+	String() string
+}
+
+type funcExpr struct {
+	text  string
+	match Filter
+}
+
+func (f funcExpr) Match(evt Event) bool {
+	return f.match(evt)
+}
+
+func (f funcExpr) String() string {
+	return f.text
+}
+
+// Func turns a filter into an expression, the description is only informative.
+func Func(description string, filter Filter) Expr {
+	return funcExpr{text: description, match: filter}
+}
+
+/*
+	Combinators, And and Or evaluate every operand so stateful filters like Held see every event.
+*/
+
+type and []Expr
+type or []Expr
+type not struct {
+	Expr
+}
+
+// And matches events matched by every filter, it matches everything without filters.
+func And(filters ...Expr) Expr {
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return and(filters)
+}
+
+// Or matches events matched by any filter, it matches nothing without filters.
+func Or(filters ...Expr) Expr {
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return or(filters)
+}
+
+// Not matches events not matched by the filter.
+func Not(filter Expr) Expr {
+	return not{filter}
+}
+
+func (f and) Match(evt Event) bool {
+	match := true
+	for _, filter := range f {
+		match = filter.Match(evt) && match
+	}
+	return match
+}
+
+func (f and) String() string {
+	if len(f) == 0 {
+		return "all"
+	}
+	texts := make([]string, len(f))
+	for idx, filter := range f {
+		texts[idx] = operand(filter, true)
+	}
+	return strings.Join(texts, " & ")
+}
+
+func (f or) Match(evt Event) bool {
+	match := false
+	for _, filter := range f {
+		match = filter.Match(evt) || match
+	}
+	return match
+}
+
+func (f or) String() string {
+	if len(f) == 0 {
+		return "none"
+	}
+	texts := make([]string, len(f))
+	for idx, filter := range f {
+		texts[idx] = operand(filter, false)
+	}
+	return strings.Join(texts, " | ")
+}
+
+func (f not) Match(evt Event) bool {
+	return !f.Expr.Match(evt)
+}
+
+func (f not) String() string {
+	switch filter := f.Expr.(type) {
+	case and:
+		if len(filter) > 0 {
+			return "!(" + filter.String() + ")"
+		}
+	case or:
+		if len(filter) > 0 {
+			return "!(" + filter.String() + ")"
+		}
+	}
+	return "!" + f.Expr.String()
+}
+
+// operand describes a filter inside a combinator, adding parentheses where the precedence requires it
+func operand(filter Expr, inAnd bool) string {
+	if f, ok := filter.(or); ok && inAnd && len(f) > 1 {
+		return "(" + filter.String() + ")"
+	}
+	return filter.String()
+}
+
+/*
+	Predicates
+*/
+
+// All matches every event.
+func All() Expr {
+	return and{}
+}
+
+// None matches no event.
+func None() Expr {
+	return or{}
+}
+
+// OfType matches events of any of the types.
+func OfType(types ...Type) Expr {
+	filters := make([]Expr, len(types))
+	for idx, tp := range types {
+		tp := tp
+		filters[idx] = funcExpr{
+			text:  strings.ToLower(tp.String()),
+			match: func(evt Event) bool { return evt.Type == tp },
+		}
+	}
+	return Or(filters...)
+}
+
+// OfButton matches events of any of the buttons.
+func OfButton(btns ...button.Button) Expr {
+	filters := make([]Expr, len(btns))
+	for idx, btn := range btns {
+		btn := btn
+		filters[idx] = funcExpr{
+			text:  btn.String(),
+			match: func(evt Event) bool { return evt.Btn == btn && !evt.Type.IsConnection() },
+		}
+	}
+	return Or(filters...)
+}
+
+// IsPad matches events of the 16 pads.
+func IsPad() Expr {
+	return buttonClass("pad", button.Button.IsPad)
+}
+
+// IsMute matches events of the 4 mute keys.
+func IsMute() Expr {
+	return buttonClass("mute", button.Button.IsMute)
+}
+
+// IsFunction matches events of the 8 function keys.
+func IsFunction() Expr {
+	return buttonClass("function", button.Button.IsFunctions)
+}
+
+// IsFader matches events of the 4 volume faders.
+func IsFader() Expr {
+	return buttonClass("fader", button.Button.IsFader)
+}
+
+// IsKnob matches events of the 4 filter knobs.
+func IsKnob() Expr {
+	return buttonClass("knob", button.Button.IsKnob)
+}
+
+// IsAnalog matches events of the faders and knobs.
+func IsAnalog() Expr {
+	return buttonClass("analog", func(btn button.Button) bool {
+		return btn.IsFader() || btn.IsKnob()
+	})
+}
+
+// IsDial matches events of the dial, turns and pushes.
+func IsDial() Expr {
+	return buttonClass("dial", func(btn button.Button) bool {
+		return btn == button.Dial
+	})
+}
+
+// IsSynthetic matches events which weren't decoded from a report of the device.
+func IsSynthetic() Expr {
+	return funcExpr{text: "synthetic", match: Event.IsSynthetic}
+}
+
+func buttonClass(text string, class func(button.Button) bool) Expr {
+	return funcExpr{
+		text: text,
+		match: func(evt Event) bool {
+			return !evt.Type.IsConnection() && class(evt.Btn)
+		},
+	}
+}
+
+// InRange matches changes of faders and knobs whose normalized value is within [min,max].
+func InRange(min, max float64) Expr {
+	return funcExpr{
+		text: fmt.Sprintf("range(%g,%g)", min, max),
+		match: func(evt Event) bool {
+			return evt.Type == Changed && (evt.Btn.IsFader() || evt.Btn.IsKnob()) &&
+				evt.Normalized >= min && evt.Normalized <= max
+		},
+	}
+}
+
+/*
+	Held matches events happening while the button is held. It tracks the button from the events it is
+	given, it must be given every event, as done by FilterChannel and Device.AddCallback.
+	The press and release of the button itself aren't matched.
+*/
+func Held(btn button.Button) Expr {
+	return &held{btn: btn}
+}
+
+type held struct {
+	btn   button.Button
+	mutex sync.Mutex
+	down  bool
+}
+
+func (f *held) Match(evt Event) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if evt.Btn == f.btn && evt.Type.IsDigital() {
+		f.down = evt.Type == Pressed
+		return false
+	}
+	if evt.Type == Disconnected {
+		f.down = false
+	}
+	return f.down
+}
+
+func (f *held) String() string {
+	return fmt.Sprintf("held(%v)", f.btn)
+}
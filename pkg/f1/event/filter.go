@@ -4,28 +4,15 @@ import (
 	"github.com/draeron/gof1/pkg/f1/button"
 )
 
+// Filter selects events, see Expr for filters which can be combined and described as text.
 type Filter func(Event) bool
 
 func IsOfType(types ...Type) Filter {
-	return func(event Event) bool {
-		for _, tp := range types {
-			if event.Type == tp {
-				return true
-			}
-		}
-		return false
-	}
+	return OfType(types...).Match
 }
 
 func IsButtonOfType(types ...button.Button) Filter {
-	return func(event Event) bool {
-		for _, tp := range types {
-			if event.Btn == tp {
-				return true
-			}
-		}
-		return false
-	}
+	return OfButton(types...).Match
 }
 
 func FilterChannel(input <-chan Event, output chan Event, filter Filter) <-chan Event {
@@ -0,0 +1,239 @@
+package event
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+)
+
+/*
+	Parse creates a filter expression from its text description, e.g. "pad & pressed & !shift".
+
+		expr     = term { "|" term }
+		term     = factor { "&" factor }
+		factor   = "!" factor | "(" expr ")" | keyword | Button | "held(" Button ")" | "range(" min "," max ")"
+
+	Keywords are the event types in lower case (pressed, released, changed, increment, decrement, connected,
+	disconnected), the button classes (pad, mute, function, fader, knob, analog, dial), synthetic, all, none
+	and shift as a shortcut for held(Shift). Buttons are named as in the button package, e.g. PadB3, except
+	Shift which is rejected so it cannot be mistaken for shift, the modifier is written held(Shift).
+*/
+func Parse(text string) (Expr, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	filter, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, errors.Errorf("unexpected '%s' in filter '%s'", p.tokens[p.pos], text)
+	}
+	return filter, nil
+}
+
+// MustParse is like Parse but panics if the text cannot be parsed.
+func MustParse(text string) Expr {
+	filter, err := Parse(text)
+	if err != nil {
+		panic(err)
+	}
+	return filter
+}
+
+var keywords = map[string]func() Expr{
+	"pad":       IsPad,
+	"mute":      IsMute,
+	"function":  IsFunction,
+	"fader":     IsFader,
+	"knob":      IsKnob,
+	"analog":    IsAnalog,
+	"dial":      IsDial,
+	"synthetic": IsSynthetic,
+	"all":       All,
+	"none":      None,
+	"shift": func() Expr {
+		return Held(button.Shift)
+	},
+}
+
+func tokenize(text string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(text)
+
+	for idx := 0; idx < len(runes); {
+		r := runes[idx]
+		switch {
+		case unicode.IsSpace(r):
+			idx++
+		case strings.ContainsRune("&|!(),", r):
+			tokens = append(tokens, string(r))
+			idx++
+		case isWordRune(r):
+			start := idx
+			for idx < len(runes) && isWordRune(runes[idx]) {
+				idx++
+			}
+			tokens = append(tokens, string(runes[start:idx]))
+		default:
+			return nil, errors.Errorf("invalid character '%c' in filter '%s'", r, text)
+		}
+	}
+	return tokens, nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' || r == '+'
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	token := p.peek()
+	if token != "" {
+		p.pos++
+	}
+	return token
+}
+
+func (p *parser) expect(token string) error {
+	if next := p.next(); next != token {
+		return p.unexpected(next, token)
+	}
+	return nil
+}
+
+func (p *parser) unexpected(token, expected string) error {
+	if token == "" {
+		return errors.Errorf("expected '%s' at end of filter", expected)
+	}
+	return errors.Errorf("expected '%s' but got '%s'", expected, token)
+}
+
+func (p *parser) expr() (Expr, error) {
+	filters := []Expr{}
+	for {
+		filter, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+
+		if p.peek() != "|" {
+			return Or(filters...), nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) term() (Expr, error) {
+	filters := []Expr{}
+	for {
+		filter, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+
+		if p.peek() != "&" {
+			return And(filters...), nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) factor() (Expr, error) {
+	token := p.next()
+
+	switch token {
+	case "":
+		return nil, errors.New("unexpected end of filter")
+
+	case "!":
+		filter, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return Not(filter), nil
+
+	case "(":
+		filter, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return filter, p.expect(")")
+
+	case "held":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		name := p.next()
+		btn, err := button.ParseButton(name)
+		if err != nil {
+			return nil, errors.Errorf("unknown button '%s'", name)
+		}
+		return Held(btn), p.expect(")")
+
+	case "range":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		min, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		max, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		return InRange(min, max), p.expect(")")
+	}
+
+	if keyword, ok := keywords[token]; ok {
+		return keyword(), nil
+	}
+
+	for tp := Pressed; tp <= Disconnected; tp++ {
+		if token == strings.ToLower(tp.String()) {
+			return OfType(tp), nil
+		}
+	}
+
+	if btn, err := button.ParseButton(token); err == nil {
+		if btn == button.Shift {
+			return nil, errors.Errorf("'%s' is ambiguous, use held(Shift) or shift for the held modifier", token)
+		}
+		return OfButton(btn), nil
+	}
+
+	return nil, errors.Errorf("unknown filter '%s'", token)
+}
+
+func (p *parser) number() (float64, error) {
+	token := p.next()
+	value, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return 0, p.unexpected(token, "number")
+	}
+	return value, nil
+}
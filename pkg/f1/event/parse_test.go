@@ -0,0 +1,173 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+)
+
+func TestParse(t *testing.T) {
+	padPressed := Event{Type: Pressed, Btn: button.PadA1, Value: 1}
+	padReleased := Event{Type: Released, Btn: button.PadA1}
+	mutePressed := Event{Type: Pressed, Btn: button.Mute2, Value: 1}
+	fader := Event{Type: Changed, Btn: button.Volume1, Value: 200, Normalized: 0.8}
+	dial := Event{Type: Increment, Btn: button.Dial, Value: 3, Delta: 1}
+
+	tests := []struct {
+		text   string
+		string string // the canonical text, it parses back to the same filter
+		match  []Event
+		skip   []Event
+	}{
+		{
+			text:   "pad & pressed",
+			string: "pad & pressed",
+			match:  []Event{padPressed},
+			skip:   []Event{padReleased, mutePressed},
+		},
+		{
+			// & binds tighter than |
+			text:   "mute | pad & released",
+			string: "mute | pad & released",
+			match:  []Event{mutePressed, padReleased},
+			skip:   []Event{padPressed, fader},
+		},
+		{
+			text:   "(mute | pad) & released",
+			string: "(mute | pad) & released",
+			match:  []Event{padReleased},
+			skip:   []Event{mutePressed, padPressed},
+		},
+		{
+			text:   "!(pad|mute)&pressed",
+			string: "!(pad | mute) & pressed",
+			match:  []Event{{Type: Pressed, Btn: button.Shift, Value: 1}},
+			skip:   []Event{padPressed, mutePressed, fader},
+		},
+		{
+			// ! only applies to the next factor
+			text:   "!pad & pressed",
+			string: "!pad & pressed",
+			match:  []Event{mutePressed},
+			skip:   []Event{padPressed, padReleased},
+		},
+		{
+			text:   "PadA1 | Volume1",
+			string: "PadA1 | Volume1",
+			match:  []Event{padPressed, padReleased, fader},
+			skip:   []Event{mutePressed},
+		},
+		{
+			text:   "fader & range(0.5, 1)",
+			string: "fader & range(0.5,1)",
+			match:  []Event{fader},
+			skip:   []Event{padPressed},
+		},
+		{
+			text:   "dial & (increment | decrement)",
+			string: "dial & (increment | decrement)",
+			match:  []Event{dial},
+			skip:   []Event{padPressed},
+		},
+	}
+
+	for _, test := range tests {
+		filter, err := Parse(test.text)
+		if err != nil {
+			t.Errorf("%q: %v", test.text, err)
+			continue
+		}
+		if filter.String() != test.string {
+			t.Errorf("%q: printed as %q, expected %q", test.text, filter.String(), test.string)
+		}
+
+		again, err := Parse(filter.String())
+		if err != nil {
+			t.Errorf("%q: printed filter doesn't parse: %v", test.text, err)
+		} else if again.String() != filter.String() {
+			t.Errorf("%q: printed as %q once parsed back", test.text, again.String())
+		}
+
+		for _, evt := range test.match {
+			if !filter.Match(evt) || !again.Match(evt) {
+				t.Errorf("%q: should match %v", test.text, evt)
+			}
+		}
+		for _, evt := range test.skip {
+			if filter.Match(evt) || again.Match(evt) {
+				t.Errorf("%q: should not match %v", test.text, evt)
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"()",
+		"pad pad",
+		"pad &",
+		"| pad",
+		"(pad",
+		"pad)",
+		"!",
+		"unknown",
+		"held(Nothing)",
+		"held(Shift",
+		"pad & pressed & !Shift", // the button would be mistaken for the modifier
+		"range(1)",
+		"range(a, 1)",
+		"pad # mute",
+	}
+
+	for _, text := range tests {
+		filter, err := Parse(text)
+		if err == nil {
+			t.Errorf("%q: parsed as %q", text, filter)
+		}
+	}
+}
+
+// TestParseHeld feeds a sequence of events to the stateful filters, in order
+func TestParseHeld(t *testing.T) {
+	shiftPressed := Event{Type: Pressed, Btn: button.Shift, Value: 1}
+	shiftReleased := Event{Type: Released, Btn: button.Shift}
+	padPressed := Event{Type: Pressed, Btn: button.PadB3, Value: 1}
+	padReleased := Event{Type: Released, Btn: button.PadB3}
+
+	sequence := []struct {
+		evt     Event
+		shifted bool // matched by "pad & pressed & held(Shift)"
+	}{
+		{padPressed, false},
+		{padReleased, false},
+		{shiftPressed, false},
+		{padPressed, true},
+		{padReleased, false},
+		{shiftReleased, false},
+		{padPressed, false},
+		{shiftPressed, false},
+		{Event{Type: Disconnected}, false},
+		{padPressed, false},
+	}
+
+	tests := []struct {
+		text    string
+		shifted bool
+	}{
+		{"pad & pressed & held(Shift)", true},
+		{"pad & pressed & shift", true},
+		{"pad & pressed & !shift", false},
+	}
+
+	for _, test := range tests {
+		filter := MustParse(test.text)
+		for idx, step := range sequence {
+			// only the pad presses are matched, with or without the modifier
+			expected := step.evt == padPressed && step.shifted == test.shifted
+			if filter.Match(step.evt) != expected {
+				t.Errorf("%q: event %d %v matched %v", test.text, idx, step.evt, !expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package event
+
+//go:generate go-enum -f=$GOFILE --noprefix
+
+// Source x ENUM(
+/*
+	Hardware
+	Initial
+	Lost
+	Injected
+*/
+// )
+type Source int
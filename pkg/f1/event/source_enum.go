@@ -0,0 +1,51 @@
+// Code generated by go-enum
+// DO NOT EDIT!
+
+package event
+
+import (
+	"fmt"
+)
+
+const (
+	// Hardware is a Source of type Hardware.
+	Hardware Source = iota
+	// Initial is a Source of type Initial.
+	Initial
+	// Lost is a Source of type Lost.
+	Lost
+	// Injected is a Source of type Injected.
+	Injected
+)
+
+const _SourceName = "HardwareInitialLostInjected"
+
+var _SourceMap = map[Source]string{
+	0: _SourceName[0:8],
+	1: _SourceName[8:15],
+	2: _SourceName[15:19],
+	3: _SourceName[19:27],
+}
+
+// String implements the Stringer interface.
+func (x Source) String() string {
+	if str, ok := _SourceMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("Source(%d)", x)
+}
+
+var _SourceValue = map[string]Source{
+	_SourceName[0:8]:   0,
+	_SourceName[8:15]:  1,
+	_SourceName[15:19]: 2,
+	_SourceName[19:27]: 3,
+}
+
+// ParseSource attempts to convert a string to a Source
+func ParseSource(name string) (Source, error) {
+	if x, ok := _SourceValue[name]; ok {
+		return x, nil
+	}
+	return Source(0), fmt.Errorf("%s is not a valid Source", name)
+}
@@ -10,11 +10,14 @@ type Controller interface {
 	EnableDebugLogger()
 	Close()
 	SetPadColors(sets button.ColorMap) error
-	Subscribe(channel chan<- event.Event)
+	Subscribe(channel chan<- event.Event) Subscription
 	String() string
 	Name() string
 }
 
+// Subscription is the handle of a subscriber of the controller events
+type Subscription = event.Subscription
+
 type Colorer interface {
 	SetPadColorAll(col color.Color) error
 	SetPadColorMany(btns []button.Button, color color.Color) error
@@ -24,9 +24,11 @@ type BasicLayout struct {
 	state      f1.ButtonStateMap
 	lastColors button.ColorMap
 	controler  f1.Controller
+	subscriber f1.Subscription
 	handlers   handlersMap
 	enabled    atomic.Bool
 	eventsCh   chan (event.Event)
+	done       chan struct{}
 	mask       f1.Mask
 	mutex      sync.RWMutex
 	ticker     *time.Ticker
@@ -57,30 +59,43 @@ func NewLayout(mask f1.Mask) *BasicLayout {
 }
 
 func (l *BasicLayout) Connect(controller f1.Controller) {
-	l.mutex.Lock()
-	l.controler = controller
-	l.mutex.Unlock()
-
 	if l.DebugName != "" {
 		log.Infof("connecting layout %s to controller %s", l.DebugName, controller.Name())
 	}
 
-	go l.tickEvents()
-	go l.tickUpdate()
+	l.mutex.Lock()
+	l.controler = controller
+	l.done = make(chan struct{})
+	l.eventsCh = make(chan event.Event, 20)
+	l.ticker = time.NewTicker(time.Second / 60)
+	l.subscriber = controller.Subscribe(l.eventsCh)
+	l.mutex.Unlock()
+
+	go l.tickEvents(l.eventsCh, l.subscriber.Done(), l.done)
+	go l.tickUpdate(l.ticker, l.done)
 }
 
 func (l *BasicLayout) Disconnect() {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 
+	if l.controler == nil {
+		return
+	}
+
 	if l.DebugName != "" {
 		log.Infof("disconnecting layout %s from controller %s", l.DebugName, l.controler.Name())
 	}
 
-	close(l.eventsCh)
+	close(l.done)
+	l.subscriber.Unsubscribe()
+	l.subscriber = nil
 	l.controler = nil
 	l.ticker.Stop()
 	l.ticker = nil
+
+	// events won't come anymore, a held button would stay pressed forever
+	l.state.ResetPressed()
 }
 
 /*
@@ -195,29 +210,34 @@ func (l *BasicLayout) SetColors(set button.ColorMap) error {
 	return nil
 }
 
-func (l *BasicLayout) tickEvents() {
-	l.mutex.Lock()
-	l.eventsCh = make(chan event.Event, 20)
-	l.controler.Subscribe(l.eventsCh)
-	l.mutex.Unlock()
-
-	for e := range l.eventsCh {
-		l.dispatch(e)
+func (l *BasicLayout) tickEvents(events <-chan event.Event, unsubscribed <-chan struct{}, done <-chan struct{}) {
+	for {
+		select {
+		case e := <-events:
+			l.dispatch(e)
+		case <-unsubscribed:
+			// controller was shut down
+			l.Disconnect()
+			return
+		case <-done:
+			return
+		}
 	}
 }
 
-func (l *BasicLayout) tickUpdate() {
-	l.mutex.Lock()
-	l.ticker = time.NewTicker(time.Second / 60)
-	l.mutex.Unlock()
-
-	for range l.ticker.C {
-		l.UpdateDevice()
+func (l *BasicLayout) tickUpdate(ticker *time.Ticker, done <-chan struct{}) {
+	for {
+		select {
+		case <-ticker.C:
+			l.UpdateDevice()
+		case <-done:
+			return
+		}
 	}
 }
 
 func (l *BasicLayout) dispatch(e event.Event) {
-	if !l.enabled.Load() || !l.mask[e.Btn] {
+	if !l.enabled.Load() || e.Type.IsConnection() || !l.mask[e.Btn] {
 		return
 	}
 	var ht HandlerType
@@ -254,7 +274,7 @@ func (l *BasicLayout) dispatch(e event.Event) {
 
 	l.mutex.Lock()
 	if e.Type == event.Pressed {
-		l.state.Press(e.Btn)
+		l.state.PressAt(e.Btn, e.Time)
 	}
 	l.mutex.Unlock()
 
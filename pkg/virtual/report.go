@@ -0,0 +1,88 @@
+package virtual
+
+import (
+	"encoding/binary"
+
+	"github.com/draeron/gof1/pkg/f1/button"
+)
+
+/*
+	The input report is encoded here independently of device.InState.MarshalBinary, so the decoding done
+	by the device is checked against a second implementation of the format.
+*/
+const (
+	inReportSize = 22
+	inReportID   = 0x01
+)
+
+// offsets of the output report sections
+const (
+	functionsOffset = 17
+	padsOffset      = 25
+)
+
+// buttons bits of the input report, in byte order, from MSB to LSB
+var inputBits = [4][]button.Button{
+	{
+		button.PadA1, button.PadA2, button.PadA3, button.PadA4,
+		button.PadB1, button.PadB2, button.PadB3, button.PadB4,
+	},
+	{
+		button.PadC1, button.PadC2, button.PadC3, button.PadC4,
+		button.PadD1, button.PadD2, button.PadD3, button.PadD4,
+	},
+	{
+		button.Shift, button.Reverse, button.Type, button.Size, button.Browse, button.Dial,
+	},
+	{
+		button.Mute1, button.Mute2, button.Mute3, button.Mute4, button.Sync, button.Quant, button.Capture,
+	},
+}
+
+// functions LEDs order in the output report
+var outputFunctions = []button.Button{
+	button.Browse,
+	button.Size,
+	button.Type,
+	button.Reverse,
+	button.Shift,
+	button.Capture,
+	button.Quant,
+	button.Sync,
+}
+
+func functionIndex(btn button.Button) int {
+	for idx, it := range outputFunctions {
+		if it == btn {
+			return idx
+		}
+	}
+	return -1
+}
+
+// packInput encodes the current controls into a 22 bytes input report, caller must hold the lock
+func (f *F1) packInput() []byte {
+	report := make([]byte, inReportSize)
+	report[0] = inReportID
+
+	for idx, btns := range inputBits {
+		for bit, btn := range btns {
+			if f.input.PressedButtons[btn] == button.Pushed {
+				report[1+idx] |= 1 << (7 - bit)
+			}
+		}
+	}
+
+	report[5] = f.input.Dial
+
+	offset := 6
+	for _, val := range f.input.Filters {
+		binary.LittleEndian.PutUint16(report[offset:], val)
+		offset += 2
+	}
+	for _, val := range f.input.Volumes {
+		binary.LittleEndian.PutUint16(report[offset:], val)
+		offset += 2
+	}
+	return report
+}
@@ -0,0 +1,323 @@
+package virtual
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/draeron/gof1/pkg/device"
+	"github.com/draeron/gof1/pkg/f1/button"
+	seven_bits "github.com/draeron/gopkgs/color/7bits"
+)
+
+/*
+	F1 is a software emulated Traktor Kontrol F1.
+
+	It implements device.Transport, so it can be given to device.OpenTransport in place of the real
+	hardware. Controls are manipulated through Press, Release, TurnDial, MoveFader and TurnKnob, each
+	call generating a new input report. Output reports written by the Device are decoded so the pad
+	colors, LED intensities and seven segment display can be read back.
+
+	Unplug and Plug simulate a disconnection of the unit, Open can be used as a device.Opener to
+	reconnect a supervised device.
+*/
+type F1 struct {
+	mutex  sync.RWMutex
+	input  *device.InState
+	output []byte
+	writes int
+
+	reports chan []byte
+	link    chan struct{} // closed when the current connection ends
+	plugged bool
+}
+
+// AnalogMax is the highest value reported by the 12 bits ADC of the faders and knobs
+const AnalogMax = device.AnalogMax
+
+const reportQueueSize = 64
+
+var ErrClosed = errors.New("virtual F1 is closed")
+var ErrUnplugged = errors.New("virtual F1 is unplugged")
+
+func New() *F1 {
+	f := &F1{
+		input:   device.NewInState(),
+		output:  make([]byte, device.OutReportSize),
+		reports: make(chan []byte, reportQueueSize),
+		link:    make(chan struct{}),
+		plugged: true,
+	}
+	f.output[0] = device.OutReportID
+	return f
+}
+
+func (f *F1) String() string {
+	return "virtual " + device.F1ProductName
+}
+
+/*
+	Input controls
+*/
+
+// Press pushes the buttons, several buttons are pressed in the same report.
+func (f *F1) Press(btns ...button.Button) error {
+	return f.setButtons(btns, button.Pushed)
+}
+
+// Release lets go of the buttons, several buttons are released in the same report.
+func (f *F1) Release(btns ...button.Button) error {
+	return f.setButtons(btns, button.Released)
+}
+
+// TurnDial rotates the encoder by a number of steps, positive steps are clockwise.
+func (f *F1) TurnDial(steps int) error {
+	f.mutex.Lock()
+	f.input.Dial = uint8(int(f.input.Dial) + steps)
+	f.mutex.Unlock()
+	return f.send()
+}
+
+func (f *F1) MoveFader(btn button.Button, value uint16) error {
+	if !btn.IsFader() {
+		return errors.Errorf("button %v is not a fader", btn)
+	}
+	f.mutex.Lock()
+	f.input.Volumes[btn-button.Volume1] = clamp(value)
+	f.mutex.Unlock()
+	return f.send()
+}
+
+func (f *F1) TurnKnob(btn button.Button, value uint16) error {
+	if !btn.IsKnob() {
+		return errors.Errorf("button %v is not a knob", btn)
+	}
+	f.mutex.Lock()
+	f.input.Filters[btn-button.Filter1] = clamp(value)
+	f.mutex.Unlock()
+	return f.send()
+}
+
+func (f *F1) setButtons(btns []button.Button, state button.PushState) error {
+	for _, btn := range btns {
+		if !btn.IsPad() && !btn.IsMute() && !btn.IsFunctions() && btn != button.Dial {
+			return errors.Errorf("button %v cannot be pressed", btn)
+		}
+	}
+	f.mutex.Lock()
+	for _, btn := range btns {
+		f.input.PressedButtons[btn] = state
+	}
+	f.mutex.Unlock()
+	return f.send()
+}
+
+// send queues a report of the current input state, blocking if the reader is lagging behind
+func (f *F1) send() error {
+	f.mutex.RLock()
+	report := f.packInput()
+	f.mutex.RUnlock()
+
+	link, err := f.connection()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case f.reports <- report:
+		return nil
+	case <-link:
+		return ErrClosed
+	}
+}
+
+/*
+	Output readback
+*/
+
+func (f *F1) PadColor(btn button.Button) (seven_bits.SevenColor, error) {
+	if !btn.IsPad() {
+		return seven_bits.SevenColor{}, errors.Errorf("button %v is not a pad", btn)
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	offset := padsOffset + int(btn-button.PadA1)*3
+	return seven_bits.SevenColor{
+		B: f.output[offset],
+		R: f.output[offset+1],
+		G: f.output[offset+2],
+	}, nil
+}
+
+// Intensity returns the LED intensity of a function or mute key, for mutes the first LED is returned.
+func (f *F1) Intensity(btn button.Button) (device.LEDIntensity, error) {
+	switch {
+	case btn.IsFunctions():
+		f.mutex.RLock()
+		defer f.mutex.RUnlock()
+		return device.LEDIntensity(f.output[functionsOffset+functionIndex(btn)]), nil
+	case btn.IsMute():
+		leds, err := f.MuteIntensities(btn)
+		return leds[0], err
+	}
+	return 0, errors.Errorf("button %v has no LED", btn)
+}
+
+// MuteIntensities returns the intensity of both LEDs of a mute key.
+func (f *F1) MuteIntensities(btn button.Button) (device.MuteLEDs, error) {
+	if !btn.IsMute() {
+		return device.MuteLEDs{}, errors.Errorf("button %v is not a mute", btn)
+	}
+	out, err := f.Output()
+	return out.Mute[btn-button.Mute1], err
+}
+
+// Display returns the state of the seven segment display.
+func (f *F1) Display() device.Display {
+	out, _ := f.Output()
+	return out.Display
+}
+
+// DisplayNumber decodes the display as a number as set by device.SetDial.
+func (f *F1) DisplayNumber() (int8, bool) {
+	return f.Display().Number()
+}
+
+// DisplayText decodes the display as characters, see device.Display.Text.
+func (f *F1) DisplayText() string {
+	return f.Display().Text()
+}
+
+// Output decodes the last output report received.
+func (f *F1) Output() (device.OutState, error) {
+	out := device.NewOutState()
+	err := out.UnmarshalBinary(f.LastReport())
+	return out, err
+}
+
+// LastReport returns a copy of the last output report received.
+func (f *F1) LastReport() []byte {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return append([]byte{}, f.output...)
+}
+
+// WriteCount returns the number of output reports received.
+func (f *F1) WriteCount() int {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.writes
+}
+
+/*
+	device.Transport implementation
+*/
+
+func (f *F1) Read(report []byte) (int, error) {
+	link, err := f.connection()
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case data := <-f.reports:
+		return copy(report, data), nil
+	case <-link:
+		return 0, ErrClosed
+	}
+}
+
+func (f *F1) Write(report []byte) (int, error) {
+	if _, err := f.connection(); err != nil {
+		return 0, err
+	}
+
+	if len(report) != device.OutReportSize {
+		return 0, &device.ErrReportLength{Report: "output", Expected: device.OutReportSize, Actual: len(report)}
+	}
+	if report[0] != device.OutReportID {
+		return 0, &device.ErrReportVersion{Report: "output", Expected: device.OutReportID, Actual: report[0]}
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	copy(f.output, report)
+	f.writes++
+	return len(report), nil
+}
+
+func (f *F1) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.disconnect()
+	return nil
+}
+
+/*
+	Connection simulation
+*/
+
+// Unplug simulates the removal of the F1, the current connection fails and the unit cannot be opened.
+func (f *F1) Unplug() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.plugged = false
+	f.disconnect()
+}
+
+// Plug simulates the F1 being connected again, its LEDs are all turned off until a new output report is received.
+func (f *F1) Plug() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.plugged = true
+	f.output = make([]byte, device.OutReportSize)
+	f.output[0] = device.OutReportID
+}
+
+// Open starts a new connection to the virtual F1, it matches the device.Opener signature.
+func (f *F1) Open() (device.Transport, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.plugged {
+		return nil, ErrUnplugged
+	}
+	f.disconnect()
+	f.link = make(chan struct{})
+
+	// the new connection starts with the current state
+	for len(f.reports) > 0 {
+		<-f.reports
+	}
+	return f, nil
+}
+
+// connection returns the channel of the current connection
+func (f *F1) connection() (chan struct{}, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	switch {
+	case !f.plugged:
+		return nil, ErrUnplugged
+	case f.link == nil:
+		return nil, ErrClosed
+	}
+	return f.link, nil
+}
+
+// disconnect ends the current connection, caller must hold the lock
+func (f *F1) disconnect() {
+	if f.link != nil {
+		close(f.link)
+		f.link = nil
+	}
+}
+
+func clamp(value uint16) uint16 {
+	if value > AnalogMax {
+		return AnalogMax
+	}
+	return value
+}